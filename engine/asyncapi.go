@@ -0,0 +1,311 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const AsyncAPIVersion = "2.6.0"
+
+type AsyncAPIDocument struct {
+	AsyncAPI   string                     `json:"asyncapi" yaml:"asyncapi"`
+	Info       AsyncAPIInfo               `json:"info" yaml:"info"`
+	Servers    map[string]AsyncAPIServer  `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Channels   map[string]AsyncAPIChannel `json:"channels" yaml:"channels"`
+	Components AsyncAPIComponents         `json:"components" yaml:"components"`
+}
+
+type AsyncAPIInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+type AsyncAPIServer struct {
+	Url      string `json:"url" yaml:"url"`
+	Protocol string `json:"protocol" yaml:"protocol"`
+}
+
+type AsyncAPIChannel struct {
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Publish     *AsyncAPIOperation `json:"publish,omitempty" yaml:"publish,omitempty"`
+	Subscribe   *AsyncAPIOperation `json:"subscribe,omitempty" yaml:"subscribe,omitempty"`
+}
+
+type AsyncAPIOperation struct {
+	OperationID string               `json:"operationId" yaml:"operationId"`
+	Summary     string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Message     AsyncAPIMessageOneOf `json:"message" yaml:"message"`
+	Tags        []AsyncAPITag        `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+type AsyncAPITag struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+type AsyncAPIMessageOneOf struct {
+	OneOf []AsyncAPIRef `json:"oneOf" yaml:"oneOf"`
+}
+
+type AsyncAPIRef struct {
+	Ref string `json:"$ref" yaml:"$ref"`
+}
+
+type AsyncAPIMessage struct {
+	Name        string            `json:"name" yaml:"name"`
+	Title       string            `json:"title,omitempty" yaml:"title,omitempty"`
+	Summary     string            `json:"summary,omitempty" yaml:"summary,omitempty"`
+	ContentType string            `json:"contentType" yaml:"contentType"`
+	Payload     *JSONSchema       `json:"payload" yaml:"payload"`
+	Examples    []AsyncAPIExample `json:"examples,omitempty" yaml:"examples,omitempty"`
+}
+
+type AsyncAPIExample struct {
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Payload any    `json:"payload" yaml:"payload"`
+}
+
+type AsyncAPIComponents struct {
+	Messages map[string]AsyncAPIMessage `json:"messages" yaml:"messages"`
+	Schemas  map[string]*JSONSchema     `json:"schemas" yaml:"schemas"`
+}
+
+func (d *AsyncAPIDocument) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+func (d *AsyncAPIDocument) YAML() ([]byte, error) {
+	return marshalYaml(d)
+}
+
+/*
+AsyncAPIGenerator exports message envelopes, i.e. top-level messages with a oneof, as payloads of a single channel.
+Envelopes annotated with @publish are sent by clients, envelopes annotated with @subscribe are received by clients.
+Without annotations, envelopes with 'Response', 'Server' or 'Event' in their names are considered as received.
+*/
+type AsyncAPIGenerator struct {
+	codegen *Codegenerator
+	info    AsyncAPIInfo
+	channel string
+	server  string
+}
+
+func NewAsyncAPIGenerator(codegen *Codegenerator, info AsyncAPIInfo, channel, server string) *AsyncAPIGenerator {
+	if channel == "" {
+		channel = "/"
+	}
+
+	return &AsyncAPIGenerator{codegen: codegen, info: info, channel: channel, server: server}
+}
+
+func (g *AsyncAPIGenerator) Generate(parsedFiles []ParsedFile) (*AsyncAPIDocument, error) {
+	schemas := newSchemaBuilder(parsedFiles, g.codegen)
+	result := &AsyncAPIDocument{
+		AsyncAPI: AsyncAPIVersion,
+		Info:     g.info,
+		Channels: make(map[string]AsyncAPIChannel),
+		Components: AsyncAPIComponents{
+			Messages: make(map[string]AsyncAPIMessage),
+			Schemas:  schemas.schemas(),
+		},
+	}
+	if result.Info.Title == "" {
+		result.Info.Title = "API"
+		if len(parsedFiles) > 0 && parsedFiles[0].Title() != "" {
+			result.Info.Title = parsedFiles[0].Title()
+		}
+	}
+	if result.Info.Version == "" {
+		result.Info.Version = "1.0.0"
+	}
+	if g.server != "" {
+		u, err := url.Parse(g.server)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server url '%s': %s", g.server, err.Error())
+		}
+		protocol := u.Scheme
+		if protocol == "" {
+			protocol = "ws"
+		}
+		result.Servers = map[string]AsyncAPIServer{"default": {Url: g.server, Protocol: protocol}}
+	}
+
+	channel := AsyncAPIChannel{}
+	for _, file := range parsedFiles {
+		for _, entry := range file.entries {
+			if entry.t != EntryTypeMessage || entry.msg.m == nil || !isEnvelope(entry.msg) {
+				continue
+			}
+			envelope := entry.msg
+			refs, err := g.envelopeMessages(result, schemas, envelope)
+			if err != nil {
+				return nil, err
+			}
+
+			target := &channel.Publish
+			if isSubscribeEnvelope(envelope) {
+				target = &channel.Subscribe
+			}
+			if *target == nil {
+				*target = &AsyncAPIOperation{}
+			}
+			(*target).Message.OneOf = append((*target).Message.OneOf, refs...)
+			(*target).Tags = append((*target).Tags, AsyncAPITag{Name: envelope.m.GetName()})
+			if (*target).Summary == "" {
+				(*target).Summary = envelope.description
+			}
+		}
+	}
+	if channel.Publish == nil && channel.Subscribe == nil {
+		return nil, fmt.Errorf("no message envelopes found, envelope is a message with a oneof field")
+	}
+	if channel.Publish != nil {
+		channel.Publish.OperationID = "publish"
+	}
+	if channel.Subscribe != nil {
+		channel.Subscribe.OperationID = "subscribe"
+	}
+	result.Channels[g.channel] = channel
+
+	return result, nil
+}
+
+/*
+envelopeMessages adds a named message for every oneof branch of the envelope and returns references to them.
+Branch message payload consists of the envelope fields that are not a part of any oneof and the branch field itself.
+*/
+func (g *AsyncAPIGenerator) envelopeMessages(doc *AsyncAPIDocument, schemas *schemaBuilder, envelope *Message) ([]AsyncAPIRef, error) {
+	var common []*MessageField
+	var branches []*MessageField
+	for i := range envelope.fields {
+		field := &envelope.fields[i]
+		if isOneofField(field) {
+			branches = append(branches, field)
+		} else {
+			common = append(common, field)
+		}
+	}
+
+	var refs []AsyncAPIRef
+	for _, branch := range branches {
+		name := branch.d.GetName()
+		if _, ok := doc.Components.Messages[name]; ok {
+			name = envelope.m.GetName() + "." + name
+		}
+		if _, ok := doc.Components.Messages[name]; ok {
+			return nil, fmt.Errorf("duplicate envelope message '%s'", name)
+		}
+
+		payload := &JSONSchema{
+			Type:        "object",
+			Description: envelope.description,
+			Properties:  make(map[string]*JSONSchema),
+		}
+		for _, field := range common {
+			payload.Properties[field.d.GetName()] = schemas.fieldSchema(field)
+		}
+		payload.Properties[branch.d.GetName()] = schemas.fieldSchema(branch)
+
+		message := AsyncAPIMessage{
+			Name:        name,
+			Title:       envelope.m.GetName() + " " + branch.d.GetName(),
+			Summary:     branch.description,
+			ContentType: "application/json",
+			Payload:     payload,
+		}
+		if msg, ok := schemas.messages[trimTypeName(branch.d.GetTypeName())]; ok && message.Summary == "" {
+			message.Summary = msg.description
+		}
+		if example, ok := envelopeExample(schemas, envelope, common, branch); ok {
+			message.Examples = append(message.Examples, AsyncAPIExample{Name: name, Payload: example})
+		}
+
+		doc.Components.Messages[name] = message
+		refs = append(refs, AsyncAPIRef{Ref: "#/components/messages/" + name})
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Ref < refs[j].Ref
+	})
+
+	return refs, nil
+}
+
+/*
+envelopeExample returns the example of the envelope payload with the branch set. Values are taken from the envelope
+example first and from the branch message one then, which is the envelope payload as well or the message wrapped in
+its name, as @autocode generates it. Keys are the field names of the payload schema, JSON names are accepted too.
+*/
+func envelopeExample(schemas *schemaBuilder, envelope *Message, common []*MessageField, branch *MessageField) (map[string]any, bool) {
+	var sources []map[string]any
+	addSource := func(msg *Message) {
+		if example, ok := schemas.example(msg); ok {
+			if source, ok := example.(map[string]any); ok {
+				sources = append(sources, source)
+			}
+		}
+	}
+	addSource(envelope)
+	branchNames := []string{branch.d.GetName()}
+	if jsonName := branch.d.GetJsonName(); jsonName != "" {
+		branchNames = append(branchNames, jsonName)
+	}
+	if msg, ok := schemas.messages[trimTypeName(branch.d.GetTypeName())]; ok {
+		addSource(msg)
+		branchNames = append(branchNames, msg.m.GetName())
+	}
+
+	result := make(map[string]any)
+	lookup := func(field *MessageField, names ...string) {
+		for _, source := range sources {
+			for _, name := range names {
+				if value, ok := source[name]; ok {
+					result[field.d.GetName()] = value
+					return
+				}
+			}
+		}
+	}
+	for _, field := range common {
+		lookup(field, field.d.GetName(), field.d.GetJsonName())
+	}
+	lookup(branch, branchNames...)
+	if _, ok := result[branch.d.GetName()]; !ok {
+		return nil, false
+	}
+
+	return result, true
+}
+
+func isEnvelope(msg *Message) bool {
+	for i := range msg.fields {
+		if isOneofField(&msg.fields[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSubscribeEnvelope returns whether the envelope is sent by the server, which is @subscribe or a Response or Event suffix
+func isSubscribeEnvelope(msg *Message) bool {
+	if hasFlag(msg.flags, SubscribeMarker) {
+		return true
+	}
+	if hasFlag(msg.flags, PublishMarker) {
+		return false
+	}
+
+	name := msg.m.GetName()
+	return strings.HasSuffix(name, "Response") || strings.HasSuffix(name, "Event")
+}
+
+func isOneofField(field *MessageField) bool {
+	if field.d == nil || field.d.FieldDescriptorProto == nil {
+		return false
+	}
+
+	return field.d.OneofIndex != nil && !field.d.GetProto3Optional()
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/pseudomuto/protokit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+	"gitlab.com/kordax/basic-utils/opt"
+	refutils "gitlab.com/kordax/basic-utils/ref-utils"
+)
+
+func TestAsyncAPIGenerator_Generate(t *testing.T) {
+	request, err := testRequest()
+	require.NoError(t, err)
+	entries, err := NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+
+	document, err := NewAsyncAPIGenerator(NewCodegenerator(), AsyncAPIInfo{}, "/ws", "wss://localhost/ws").Generate(entries)
+	require.NoError(t, err)
+
+	assert.Equal(t, AsyncAPIVersion, document.AsyncAPI)
+	assert.Equal(t, "wss", document.Servers["default"].Protocol)
+
+	channel, ok := document.Channels["/ws"]
+	require.True(t, ok)
+	require.NotNil(t, channel.Publish)
+	require.NotNil(t, channel.Subscribe)
+	assert.Equal(t, []AsyncAPIRef{
+		{Ref: "#/components/messages/registration_request"},
+		{Ref: "#/components/messages/token_request"},
+	}, channel.Publish.Message.OneOf)
+	assert.Equal(t, []AsyncAPIRef{
+		{Ref: "#/components/messages/login_request"},
+		{Ref: "#/components/messages/token_response"},
+	}, channel.Subscribe.Message.OneOf)
+
+	t.Run("branch message payload", func(t *testing.T) {
+		message := document.Components.Messages["registration_request"]
+		assert.Equal(t, "application/json", message.ContentType)
+		assert.Contains(t, message.Payload.Properties, "trx")
+		assert.Equal(t, SchemaRefPrefix+"doc_generator_test.RegistrationRequest", message.Payload.Properties["registration_request"].Ref)
+		assert.NotContains(t, message.Payload.Properties, "token_request")
+		assert.Len(t, message.Examples, 1)
+	})
+
+	t.Run("schemas with constraints", func(t *testing.T) {
+		registration := document.Components.Schemas["doc_generator_test.RegistrationRequest"]
+		require.NotNil(t, registration)
+		assert.Equal(t, 10, *registration.Properties["phone"].MaxLength)
+	})
+
+	t.Run("code examples", func(t *testing.T) {
+		message := document.Components.Messages["token_request"]
+		require.Len(t, message.Examples, 1)
+		payload, ok := message.Examples[0].Payload.(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, "783b9df7-4ab2-481d-8a26-cf30908b673f", payload["trx"])
+		assert.Contains(t, payload, "token_request")
+		assert.Len(t, payload, 2)
+	})
+
+	t.Run("autocode examples", func(t *testing.T) {
+		message := document.Components.Messages["registration_request"]
+		require.Len(t, message.Examples, 1)
+		payload, ok := message.Examples[0].Payload.(map[string]any)
+		require.True(t, ok)
+		assert.ElementsMatch(t, []string{"trx", "registration_request"}, unionKeys(payload, nil))
+		assert.Contains(t, payload["registration_request"], "phone")
+	})
+
+	t.Run("envelope examples", func(t *testing.T) {
+		for _, entry := range entries[0].entries {
+			if entry.msg != nil && entry.msg.m.GetName() == "ClientRequest" {
+				entry.msg.code = opt.Of(arrayutils.Pair[Syntax, string]{Left: SyntaxJson, Right: `{"trx": "envelope", "tokenRequest": {"username": "alice"}}`})
+			}
+		}
+		document, err := NewAsyncAPIGenerator(NewCodegenerator(), AsyncAPIInfo{}, "/ws", "").Generate(entries)
+		require.NoError(t, err)
+
+		token := document.Components.Messages["token_request"].Examples[0].Payload.(map[string]any)
+		assert.Equal(t, map[string]any{"trx": "envelope", "token_request": map[string]any{"username": "alice"}}, token)
+		registration := document.Components.Messages["registration_request"].Examples[0].Payload.(map[string]any)
+		assert.Equal(t, "envelope", registration["trx"])
+		assert.Contains(t, registration, "registration_request")
+	})
+
+	t.Run("marshalling", func(t *testing.T) {
+		content, err := document.JSON()
+		require.NoError(t, err)
+		assert.True(t, json.Valid(content))
+
+		content, err = document.YAML()
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "asyncapi: "+AsyncAPIVersion)
+	})
+}
+
+func TestAsyncAPIGenerator_NoEnvelopes(t *testing.T) {
+	_, err := NewAsyncAPIGenerator(nil, AsyncAPIInfo{}, "", "").Generate([]ParsedFile{createMockParsedFile()})
+	assert.Error(t, err)
+}
+
+func TestIsSubscribeEnvelope(t *testing.T) {
+	mkMessage := func(name string, flags ...string) *Message {
+		return &Message{
+			m:     &protokit.Descriptor{DescriptorProto: &descriptor.DescriptorProto{Name: refutils.Ref(name)}},
+			flags: flags,
+		}
+	}
+
+	assert.False(t, isSubscribeEnvelope(mkMessage("ClientRequest")))
+	assert.True(t, isSubscribeEnvelope(mkMessage("ServerResponse")))
+	assert.True(t, isSubscribeEnvelope(mkMessage("OrderEvent")))
+	assert.False(t, isSubscribeEnvelope(mkMessage("ListServersRequest")))
+	assert.False(t, isSubscribeEnvelope(mkMessage("ResponseCache")))
+	assert.True(t, isSubscribeEnvelope(mkMessage("ClientRequest", "subscribe")))
+	assert.False(t, isSubscribeEnvelope(mkMessage("ServerResponse", "publish */")))
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type fieldReference struct {
+	message *Message
+	field   *MessageField
+}
+
+// referenceIndex maps message and enum full names to the fields, which refer to them
+type referenceIndex map[string][]fieldReference
+
+/*
+buildReferenceIndex collects fields of the documented messages of all files, which refer to messages or enums.
+References are kept in the files and messages order.
+*/
+func buildReferenceIndex(parsedFiles []ParsedFile) referenceIndex {
+	index := make(referenceIndex)
+	for _, file := range parsedFiles {
+		for _, entry := range file.entries {
+			if entry.t != EntryTypeMessage || entry.msg.m == nil {
+				continue
+			}
+			for i := range entry.msg.fields {
+				field := &entry.msg.fields[i]
+				switch field.d.GetType() {
+				case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+					typeName := strings.TrimPrefix(field.d.GetTypeName(), ".")
+					index[typeName] = append(index[typeName], fieldReference{message: entry.msg, field: field})
+				}
+			}
+		}
+	}
+
+	return index
+}
+
+// usedBy adds the list of fields referring to the type, nothing is added for unused types
+func (g *MDGenerator) usedBy(fullName string, refs referenceIndex, section *md.Section) {
+	references := refs[fullName]
+	if len(references) == 0 {
+		return
+	}
+
+	paragraph := MkParagraph()
+	TextToParagraph(paragraph, g.headings.UsedBy, md.TextEmphasisBold)
+	section.AddElement(paragraph)
+
+	list := MkList(false, nil)
+	for _, reference := range references {
+		text := reference.message.m.GetName() + "." + reference.field.d.GetName()
+		list.AddEntry(MkListEntry(list, MkLink(text, reference.message.m.GetFullName())))
+	}
+	section.AddElement(list)
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReferenceIndex(t *testing.T) {
+	entries, err := NewDescriptorParser(splitTestRequest(t)).Parse()
+	require.NoError(t, err)
+
+	refs := buildReferenceIndex(entries)
+	require.Len(t, refs["common.Money"], 1)
+	assert.Equal(t, "Order", refs["common.Money"][0].message.m.GetName())
+	assert.Equal(t, "price", refs["common.Money"][0].field.d.GetName())
+	require.Len(t, refs["common.Currency"], 1)
+	assert.Equal(t, "currency", refs["common.Currency"][0].field.d.GetName())
+	assert.Empty(t, refs["shop.Order"])
+}
+
+func TestMDGenerator_usedBy(t *testing.T) {
+	entries, err := NewDescriptorParser(splitTestRequest(t)).Parse()
+	require.NoError(t, err)
+
+	render := func(page Page) string {
+		result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), page.Document)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("single document", func(t *testing.T) {
+		document, err := NewMDGenerator(NewCodegenerator()).Generate(entries)
+		require.NoError(t, err)
+		result := render(Page{Document: document})
+		assert.Contains(t, result, "**Used by:**")
+		assert.Contains(t, result, "[Money.currency](#common-money)")
+		assert.Contains(t, result, "[Order.price](#shop-order)")
+		assert.Contains(t, result, "[Order.item](#shop-order)")
+	})
+
+	t.Run("split pages", func(t *testing.T) {
+		pages, err := NewMDGenerator(NewCodegenerator()).GenerateSplit(entries, SplitModeFile)
+		require.NoError(t, err)
+		require.Len(t, pages, 3)
+		assert.Contains(t, render(pages[1]), "[Order.price](api_orders.md#shop-order)")
+		assert.Contains(t, render(pages[2]), "[Order.item](#shop-order)")
+	})
+}
@@ -0,0 +1,370 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	"github.com/pseudomuto/protokit"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type Severity int
+
+const (
+	SeverityNone Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+)
+
+var severityNames = map[Severity]string{
+	SeverityNone:    "none",
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+func ParseSeverity(severity string) (Severity, error) {
+	for s, name := range severityNames {
+		if name == strings.ToLower(severity) {
+			return s, nil
+		}
+	}
+
+	return SeverityNone, fmt.Errorf("unsupported severity: '%s', supported values are: none, info, warning, error", severity)
+}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	severity, err := ParseSeverity(name)
+	if err != nil {
+		return err
+	}
+	*s = severity
+
+	return nil
+}
+
+type BreakingRule string
+
+const (
+	// RuleFieldNumberReused breaks the wire format, old messages are decoded into a field of another meaning
+	RuleFieldNumberReused BreakingRule = "FIELD_NUMBER_REUSED"
+	// RuleFieldNumberChanged breaks the wire format
+	RuleFieldNumberChanged BreakingRule = "FIELD_NUMBER_CHANGED"
+	// RuleFieldTypeChanged breaks the wire format, labels are a part of the type
+	RuleFieldTypeChanged BreakingRule = "FIELD_TYPE_CHANGED"
+	// RuleFieldRemoved is reported for removed fields, which number or name isn't reserved, so it can be reused later
+	RuleFieldRemoved BreakingRule = "FIELD_REMOVED"
+	// RuleFieldRenamed breaks JSON, where fields are encoded by name
+	RuleFieldRenamed BreakingRule = "FIELD_RENAMED"
+	// RuleEnumValueRemoved breaks JSON and the code, which handles the value
+	RuleEnumValueRemoved BreakingRule = "ENUM_VALUE_REMOVED"
+	// RuleEnumValueNumberChanged breaks the wire format
+	RuleEnumValueNumberChanged BreakingRule = "ENUM_VALUE_NUMBER_CHANGED"
+	// RuleEnumValueRenamed breaks JSON, where enum values are encoded by name
+	RuleEnumValueRenamed BreakingRule = "ENUM_VALUE_RENAMED"
+	// RuleConstraintTightened is reported for added or narrowed @min, @max and @len, previously valid values are rejected
+	RuleConstraintTightened BreakingRule = "CONSTRAINT_TIGHTENED"
+)
+
+type BreakingChange struct {
+	Rule     BreakingRule `json:"rule"`
+	Severity Severity     `json:"severity"`
+	Name     string       `json:"name"`
+	Message  string       `json:"message"`
+}
+
+type BreakingReport struct {
+	Changes []BreakingChange `json:"changes"`
+}
+
+func (r *BreakingReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Severity returns the highest severity of the changes, SeverityNone if there are no changes
+func (r *BreakingReport) Severity() Severity {
+	result := SeverityNone
+	for _, change := range r.Changes {
+		if change.Severity > result {
+			result = change.Severity
+		}
+	}
+
+	return result
+}
+
+func (r *BreakingReport) add(rule BreakingRule, severity Severity, name, message string, args ...any) {
+	r.Changes = append(r.Changes, BreakingChange{Rule: rule, Severity: severity, Name: name, Message: fmt.Sprintf(message, args...)})
+}
+
+/*
+DetectBreaking checks the new API version for wire and JSON incompatible changes of the messages and enums, which are present
+in both versions. Wire format breaking changes are errors, JSON breaking and tightened constraints are warnings.
+*/
+func DetectBreaking(oldFiles, newFiles []ParsedFile) *BreakingReport {
+	report := &BreakingReport{Changes: make([]BreakingChange, 0)}
+	oldApi, newApi := newApiIndex(oldFiles), newApiIndex(newFiles)
+
+	for _, name := range unionKeys(oldApi.messages, newApi.messages) {
+		oldMsg, inOld := oldApi.messages[name]
+		newMsg, inNew := newApi.messages[name]
+		if inOld && inNew {
+			report.messageFields(name, oldMsg, newMsg)
+		}
+	}
+	for _, name := range unionKeys(oldApi.enums, newApi.enums) {
+		oldEnum, inOld := oldApi.enums[name]
+		newEnum, inNew := newApi.enums[name]
+		if inOld && inNew {
+			report.enumValues(name, oldEnum, newEnum)
+		}
+	}
+
+	return report
+}
+
+func (r *BreakingReport) messageFields(message string, oldMsg, newMsg *Message) {
+	newByName, newByNumber := make(map[string]*MessageField), make(map[int32]*MessageField)
+	for i := range newMsg.fields {
+		newByName[newMsg.fields[i].d.GetName()] = &newMsg.fields[i]
+		newByNumber[newMsg.fields[i].d.GetNumber()] = &newMsg.fields[i]
+	}
+	oldByName, oldByNumber := make(map[string]*MessageField), make(map[int32]*MessageField)
+	for i := range oldMsg.fields {
+		oldByName[oldMsg.fields[i].d.GetName()] = &oldMsg.fields[i]
+		oldByNumber[oldMsg.fields[i].d.GetNumber()] = &oldMsg.fields[i]
+	}
+
+	for _, oldField := range sortedFields(oldMsg.fields) {
+		name, number := oldField.d.GetName(), oldField.d.GetNumber()
+		fullName := message + "." + name
+		if newField, ok := newByName[name]; ok {
+			if newField.d.GetNumber() != number {
+				r.add(RuleFieldNumberChanged, SeverityError, fullName, "field number changed from %d to %d", number, newField.d.GetNumber())
+			}
+			if !sameFieldType(oldField, newField) {
+				r.add(RuleFieldTypeChanged, SeverityError, fullName, "field type changed from '%s' to '%s'", protoFieldType(oldField), protoFieldType(newField))
+			}
+			r.constraints(fullName, oldField.flags.OrElse(FieldFlags{}), newField.flags.OrElse(FieldFlags{}))
+			continue
+		}
+
+		switch newField, ok := newByNumber[number]; {
+		case ok && sameFieldType(oldField, newField):
+			r.add(RuleFieldRenamed, SeverityWarning, fullName, "field %d renamed to '%s', which breaks JSON", number, newField.d.GetName())
+		case ok:
+			r.add(RuleFieldNumberReused, SeverityError, fullName, "field number %d is reused by '%s' of type '%s'", number, newField.d.GetName(), protoFieldType(newField))
+		case !messageReserved(newMsg, number):
+			r.add(RuleFieldRemoved, SeverityError, fullName, "field removed without reserving its number %d", number)
+		}
+	}
+
+	// numbers of the fields, which moved to another number, can be taken by new fields
+	for _, newField := range sortedFields(newMsg.fields) {
+		if _, ok := oldByName[newField.d.GetName()]; ok {
+			continue
+		}
+		if oldField, ok := oldByNumber[newField.d.GetNumber()]; ok {
+			if _, moved := newByName[oldField.d.GetName()]; moved {
+				r.add(RuleFieldNumberReused, SeverityError, message+"."+newField.d.GetName(), "field number %d of '%s' is reused", newField.d.GetNumber(), oldField.d.GetName())
+			}
+		}
+	}
+}
+
+func (r *BreakingReport) constraints(field string, oldFlags, newFlags FieldFlags) {
+	if newMin := newFlags.min.Get(); newMin != nil {
+		if oldMin := oldFlags.min.Get(); oldMin == nil || *newMin > *oldMin {
+			r.add(RuleConstraintTightened, SeverityWarning, field, "@min tightened from %s to %s", constraintString(oldMin, formatFloat), formatFloat(*newMin))
+		}
+	}
+	if newMax := newFlags.max.Get(); newMax != nil {
+		if oldMax := oldFlags.max.Get(); oldMax == nil || *newMax < *oldMax {
+			r.add(RuleConstraintTightened, SeverityWarning, field, "@max tightened from %s to %s", constraintString(oldMax, formatFloat), formatFloat(*newMax))
+		}
+	}
+	if newLen := newFlags.maxLength.Get(); newLen != nil {
+		if oldLen := oldFlags.maxLength.Get(); oldLen == nil || *newLen < *oldLen {
+			r.add(RuleConstraintTightened, SeverityWarning, field, "@len tightened from %s to %d", constraintString(oldLen, strconv.Itoa), *newLen)
+		}
+	}
+}
+
+func (r *BreakingReport) enumValues(enum string, oldEnum, newEnum *Enum) {
+	newByName, newByNumber := make(map[string]*EnumField), make(map[int32]*EnumField)
+	for i := range newEnum.values {
+		newByName[newEnum.values[i].d.GetName()] = &newEnum.values[i]
+		if _, ok := newByNumber[newEnum.values[i].d.GetNumber()]; !ok {
+			newByNumber[newEnum.values[i].d.GetNumber()] = &newEnum.values[i]
+		}
+	}
+
+	for _, oldValue := range oldEnum.values {
+		name, number := oldValue.d.GetName(), oldValue.d.GetNumber()
+		fullName := enum + "." + name
+		if newValue, ok := newByName[name]; ok {
+			if newValue.d.GetNumber() != number {
+				r.add(RuleEnumValueNumberChanged, SeverityError, fullName, "enum value number changed from %d to %d", number, newValue.d.GetNumber())
+			}
+			continue
+		}
+
+		switch newValue, ok := newByNumber[number]; {
+		case ok:
+			r.add(RuleEnumValueRenamed, SeverityWarning, fullName, "enum value %d renamed to '%s', which breaks JSON", number, newValue.d.GetName())
+		case enumReserved(newEnum, name, number):
+			r.add(RuleEnumValueRemoved, SeverityWarning, fullName, "reserved enum value removed, which breaks JSON")
+		default:
+			r.add(RuleEnumValueRemoved, SeverityError, fullName, "enum value removed without reserving its number %d or name", number)
+		}
+	}
+}
+
+/*
+Markdown returns the report document with the changes grouped by severity, the most severe first.
+*/
+func (r *BreakingReport) Markdown() *md.Document {
+	document := md.NewDocumentBuilder().Build()
+	section := md.NewSectionBuilder().Build()
+	section.AddElement(MkHeader("Breaking Changes", md.HeaderLevelOne))
+	if len(r.Changes) == 0 {
+		section.AddElement(MkText("No breaking changes.", md.TextEmphasisNormal))
+	}
+
+	for _, severity := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		list := MkList(false, nil)
+		for _, change := range r.Changes {
+			if change.Severity != severity {
+				continue
+			}
+			paragraph := MkParagraph()
+			TextToParagraph(paragraph, string(change.Rule), md.TextEmphasisBold)
+			TextToParagraph(paragraph, change.Name, md.TextEmphasisCode)
+			TextToParagraph(paragraph, change.Message, md.TextEmphasisNormal)
+			list.AddEntry(MkListEntry(list, paragraph))
+		}
+		if len(list.GetEntries()) == 0 {
+			continue
+		}
+
+		name := severity.String()
+		section.AddElement(MkHeader(strings.ToUpper(name[:1])+name[1:]+"s", md.HeaderLevelTwo))
+		section.AddElement(list)
+	}
+	document.AddSection(section)
+
+	return document
+}
+
+func constraintString[T any](value *T, format func(v T) string) string {
+	if value == nil {
+		return "none"
+	}
+
+	return format(*value)
+}
+
+/*
+sameFieldType compares the wire types instead of the displayed ones, e.g. int64 and sint64 are different types.
+Map fields are compared by their key and value types, the names of their entry messages follow the field names.
+*/
+func sameFieldType(a, b *MessageField) bool {
+	aEntry, bEntry := mapEntry(a), mapEntry(b)
+	if aEntry != nil && bEntry != nil {
+		return sameWireType(aEntry.GetMessageField("key"), bEntry.GetMessageField("key")) &&
+			sameWireType(aEntry.GetMessageField("value"), bEntry.GetMessageField("value"))
+	}
+
+	return sameWireType(a.d, b.d)
+}
+
+func sameWireType(a, b *protokit.FieldDescriptor) bool {
+	return a.GetType() == b.GetType() && a.GetTypeName() == b.GetTypeName() && a.GetLabel() == b.GetLabel()
+}
+
+// mapEntry returns the map entry message of the map field, which is nested in the field's message, or nil for other fields
+func mapEntry(field *MessageField) *protokit.Descriptor {
+	if field.m == nil || field.d.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return nil
+	}
+	typeName := trimTypeName(field.d.GetTypeName())
+	for _, nested := range field.m.GetMessages() {
+		if nested.GetFullName() == typeName && isMapEntry(nested) {
+			return nested
+		}
+	}
+
+	return nil
+}
+
+// protoFieldType returns the field type as it's declared in .proto files with its label, e.g. 'repeated sint64' or 'map<string, int32>'
+func protoFieldType(field *MessageField) string {
+	if entry := mapEntry(field); entry != nil {
+		return fmt.Sprintf("map<%s, %s>", protoTypeName(entry.GetMessageField("key")), protoTypeName(entry.GetMessageField("value")))
+	}
+	typeName := protoTypeName(field.d)
+	if label := strings.ToLower(pbLabel(field.d)); label != "" {
+		return strings.TrimPrefix(label, "label_") + " " + typeName
+	}
+
+	return typeName
+}
+
+func sortedFields(fields []MessageField) []*MessageField {
+	result := make([]*MessageField, 0, len(fields))
+	for i := range fields {
+		result = append(result, &fields[i])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].d.GetNumber() < result[j].d.GetNumber()
+	})
+
+	return result
+}
+
+/*
+messageReserved returns whether the field number is reserved, reserved ranges of messages are end exclusive.
+A reserved name alone doesn't prevent the number from being reused by a new field, so it's not checked.
+*/
+func messageReserved(message *Message, number int32) bool {
+	for _, reserved := range message.m.GetReservedRange() {
+		if number >= reserved.GetStart() && number < reserved.GetEnd() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enumReserved returns whether the value name or number is reserved, reserved ranges of enums are end inclusive
+func enumReserved(enum *Enum, name string, number int32) bool {
+	for _, reserved := range enum.e.GetReservedName() {
+		if reserved == name {
+			return true
+		}
+	}
+	for _, reserved := range enum.e.GetReservedRange() {
+		if number >= reserved.GetStart() && number <= reserved.GetEnd() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestDetectBreaking(t *testing.T) {
+	amountComment := func(common *descriptorpb.FileDescriptorProto, comment string) {
+		common.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{{
+			Path:             []int32{4, 0, 2, 0},
+			Span:             []int32{4, 2, 20},
+			TrailingComments: proto.String(comment),
+		}}}
+	}
+	currencies := func(common *descriptorpb.FileDescriptorProto, values ...string) {
+		for i, value := range values {
+			common.EnumType[0].Value = append(common.EnumType[0].Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String(value), Number: proto.Int32(int32(i + 1))})
+		}
+	}
+
+	oldFiles := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		amountComment(common, " amount in cents @max=1000\n")
+		currencies(common, "EUR", "GBP")
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		report := DetectBreaking(oldFiles, oldFiles)
+		assert.Empty(t, report.Changes)
+		assert.Equal(t, SeverityNone, report.Severity())
+	})
+
+	newFiles := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		amountComment(common, " amount in cents @min=1 @max=100\n")
+		currencies(common, "DELETED", "POUND")
+		common.EnumType[0].ReservedName = []string{"EUR"}
+		common.MessageType[0].Field[1].Name = proto.String("currency_code")
+
+		orders.MessageType[0].Field[1] = &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String("note"),
+			Number: proto.Int32(2),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		}
+		orders.MessageType[0].Field[0].Number = proto.Int32(3)
+		orders.MessageType[1].Field = nil
+		orders.MessageType[1].ReservedRange = []*descriptorpb.DescriptorProto_ReservedRange{{Start: proto.Int32(1), End: proto.Int32(2)}}
+	})
+	report := DetectBreaking(oldFiles, newFiles)
+
+	t.Run("changes", func(t *testing.T) {
+		assert.Equal(t, []BreakingChange{
+			{Rule: RuleConstraintTightened, Severity: SeverityWarning, Name: "common.Money.amount", Message: "@min tightened from none to 1"},
+			{Rule: RuleConstraintTightened, Severity: SeverityWarning, Name: "common.Money.amount", Message: "@max tightened from 1000 to 100"},
+			{Rule: RuleFieldRenamed, Severity: SeverityWarning, Name: "common.Money.currency", Message: "field 2 renamed to 'currency_code', which breaks JSON"},
+			{Rule: RuleFieldNumberChanged, Severity: SeverityError, Name: "shop.Order.price", Message: "field number changed from 1 to 3"},
+			{Rule: RuleFieldNumberReused, Severity: SeverityError, Name: "shop.Order.item", Message: "field number 2 is reused by 'note' of type 'string'"},
+			{Rule: RuleEnumValueRenamed, Severity: SeverityWarning, Name: "common.Currency.EUR", Message: "enum value 1 renamed to 'DELETED', which breaks JSON"},
+			{Rule: RuleEnumValueRenamed, Severity: SeverityWarning, Name: "common.Currency.GBP", Message: "enum value 2 renamed to 'POUND', which breaks JSON"},
+		}, report.Changes)
+		assert.Equal(t, SeverityError, report.Severity())
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		files := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+			amountComment(common, " amount in cents @max=1000\n")
+			currencies(common, "EUR")
+			common.EnumType[0].ReservedRange = []*descriptorpb.EnumDescriptorProto_EnumReservedRange{{Start: proto.Int32(2), End: proto.Int32(2)}}
+			common.MessageType[0].Field = common.MessageType[0].Field[:1]
+			orders.MessageType[0].Field = orders.MessageType[0].Field[:1]
+			orders.MessageType[0].ReservedRange = []*descriptorpb.DescriptorProto_ReservedRange{{Start: proto.Int32(2), End: proto.Int32(3)}}
+		})
+		assert.Equal(t, []BreakingChange{
+			{Rule: RuleFieldRemoved, Severity: SeverityError, Name: "common.Money.currency", Message: "field removed without reserving its number 2"},
+			{Rule: RuleEnumValueRemoved, Severity: SeverityWarning, Name: "common.Currency.GBP", Message: "reserved enum value removed, which breaks JSON"},
+		}, DetectBreaking(oldFiles, files).Changes)
+	})
+
+	t.Run("reserved name", func(t *testing.T) {
+		files := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+			amountComment(common, " amount in cents @max=1000\n")
+			currencies(common, "EUR", "GBP")
+			orders.MessageType[1].Field = nil
+			orders.MessageType[1].ReservedName = []string{"name"}
+		})
+		assert.Equal(t, []BreakingChange{
+			{Rule: RuleFieldRemoved, Severity: SeverityError, Name: "shop.Item.name", Message: "field removed without reserving its number 1"},
+		}, DetectBreaking(oldFiles, files).Changes)
+	})
+
+	t.Run("wire types", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			newType descriptorpb.FieldDescriptorProto_Type
+			message string
+		}{
+			{"sint64", descriptorpb.FieldDescriptorProto_TYPE_SINT64, "field type changed from 'int64' to 'sint64'"},
+			{"fixed64", descriptorpb.FieldDescriptorProto_TYPE_FIXED64, "field type changed from 'int64' to 'fixed64'"},
+			{"sfixed64", descriptorpb.FieldDescriptorProto_TYPE_SFIXED64, "field type changed from 'int64' to 'sfixed64'"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				files := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+					amountComment(common, " amount in cents @max=1000\n")
+					currencies(common, "EUR", "GBP")
+					common.MessageType[0].Field[0].Type = tt.newType.Enum()
+				})
+				assert.Equal(t, []BreakingChange{
+					{Rule: RuleFieldTypeChanged, Severity: SeverityError, Name: "common.Money.amount", Message: tt.message},
+				}, DetectBreaking(oldFiles, files).Changes)
+			})
+		}
+	})
+
+	t.Run("map value type", func(t *testing.T) {
+		int32Tags := diffTestFiles(t, func(_, orders *descriptorpb.FileDescriptorProto) {
+			tagsMap(orders, descriptorpb.FieldDescriptorProto_TYPE_INT32)
+		})
+		stringTags := diffTestFiles(t, func(_, orders *descriptorpb.FileDescriptorProto) {
+			tagsMap(orders, descriptorpb.FieldDescriptorProto_TYPE_STRING)
+		})
+		assert.Equal(t, []BreakingChange{
+			{Rule: RuleFieldTypeChanged, Severity: SeverityError, Name: "shop.Order.tags", Message: "field type changed from 'map<string, int32>' to 'map<string, string>'"},
+		}, DetectBreaking(int32Tags, stringTags).Changes)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), report.Markdown())
+		require.NoError(t, err)
+		assert.Contains(t, result, "# Breaking Changes")
+		assert.Contains(t, result, "## Errors\n\n* **FIELD_NUMBER_CHANGED** `shop.Order.price` field number changed from 1 to 3")
+		assert.Contains(t, result, "## Warnings\n\n* **CONSTRAINT_TIGHTENED** `common.Money.amount` @min tightened from none to 1")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		content, err := report.JSON()
+		require.NoError(t, err)
+		var decoded BreakingReport
+		require.NoError(t, json.Unmarshal(content, &decoded))
+		assert.Equal(t, report.Changes, decoded.Changes)
+		assert.Contains(t, string(content), `"severity": "warning"`)
+	})
+}
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := ParseSeverity("Warning")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityWarning, severity)
+
+	_, err = ParseSeverity("fatal")
+	assert.Error(t, err)
+}
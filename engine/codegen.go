@@ -4,15 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
-	"strconv"
+	"sort"
+	"strings"
 	"time"
-	"unicode/utf8"
 
 	"github.com/google/uuid"
-	"github.com/goombaio/namegenerator"
 	"github.com/kordax/pb-md5-generator/engine/md"
-	"github.com/kordax/pb-md5-generator/engine/password"
+	"github.com/pseudomuto/protokit"
 	"github.com/rs/zerolog/log"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
 )
 
 type Syntax int
@@ -22,45 +22,114 @@ const (
 	SyntaxXml
 )
 
-type ValueType int
+// Language returns code block language of the syntax.
+func (s Syntax) Language() string {
+	switch s {
+	case SyntaxXml:
+		return "xml"
+	default:
+		return "json"
+	}
+}
+
+/*
+ValueType is a name of value generator, either detected from the field type or set with @type.
+Custom types are served by generators registered with Codegenerator.RegisterValueGenerator.
+*/
+type ValueType string
 
 const (
-	ValueTypeInt ValueType = iota
-	ValueTypeUInt
-	ValueTypeFloat
-	ValueTypeBool
-	ValueTypeString
-	ValueTypeEnum
-	ValueTypeJWT
-	ValueTypeUUID
-	ValueTypeStruct
-	ValueTypeEmail
-	ValueTypePhone
-	ValueTypePassword
+	ValueTypeInt      ValueType = "int"
+	ValueTypeUInt     ValueType = "uint"
+	ValueTypeFloat    ValueType = "float"
+	ValueTypeBool     ValueType = "bool"
+	ValueTypeString   ValueType = "string"
+	ValueTypeEnum     ValueType = "enum"
+	ValueTypeJWT      ValueType = "jwt"
+	ValueTypeUUID     ValueType = "uuid"
+	ValueTypeStruct   ValueType = "struct"
+	ValueTypeEmail    ValueType = "email"
+	ValueTypePhone    ValueType = "phone"
+	ValueTypePassword ValueType = "password"
 )
 
-var passGen = password.NewGenerator(1, 7, 5, 1)
-
 type Codegenerator struct {
-	namegen namegenerator.Generator
+	rnd               *rand.Rand
+	generators        map[ValueType]ValueGenerator
+	enums             map[string]*Enum
+	includeDeprecated bool
+	now               func() time.Time
 }
 
+// SeededExampleTime is the current time of seeded generators, e.g. the iat claim of JWT examples
+var SeededExampleTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 func NewCodegenerator() *Codegenerator {
-	return &Codegenerator{
-		namegen: namegenerator.NewNameGenerator(time.Now().UnixNano()),
+	g := NewSeededCodegenerator(time.Now().UnixNano())
+	g.now = time.Now
+
+	return g
+}
+
+/*
+NewSeededCodegenerator creates a generator that produces the same examples for the same seed.
+Its clock is fixed at SeededExampleTime, use SetClock to change it.
+*/
+func NewSeededCodegenerator(seed int64) *Codegenerator {
+	g := &Codegenerator{
+		rnd:        rand.New(rand.NewSource(seed)),
+		generators: builtinValueGenerators(),
+		enums:      make(map[string]*Enum),
+		now: func() time.Time {
+			return SeededExampleTime
+		},
+	}
+	g.generators[ValueTypeEnum] = ValueGeneratorFunc(g.generateEnum)
+	g.generators[ValueTypeJWT] = ValueGeneratorFunc(g.generateJWT)
+
+	return g
+}
+
+// RegisterValueGenerator registers generator for the value type, built-in generators can be overridden as well.
+func (g *Codegenerator) RegisterValueGenerator(t ValueType, generator ValueGenerator) {
+	g.generators[ValueType(strings.ToLower(string(t)))] = generator
+}
+
+// SetClock sets the current time source of the time based examples
+func (g *Codegenerator) SetClock(now func() time.Time) {
+	g.now = now
+}
+
+// ValueTypes returns the sorted types of the registered value generators, which DescriptorParser.SetValueTypes accepts
+func (g *Codegenerator) ValueTypes() []ValueType {
+	types := make([]ValueType, 0, len(g.generators))
+	for t := range g.generators {
+		types = append(types, t)
 	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+
+	return types
+}
+
+// SetIncludeDeprecated keeps deprecated fields and enum values in generated examples, they are dropped by default
+func (g *Codegenerator) SetIncludeDeprecated(include bool) {
+	g.includeDeprecated = include
 }
 
 func (g *Codegenerator) Generate(files []ParsedFile, message *Message) (*md.Codeblock, error) {
 	if message.code.Present() {
-		result := md.NewCodeblockBuilder().Text(message.code.Get().Right).Build()
+		code := message.code.Get()
+		result := md.NewCodeblockBuilder().Text(code.Right).Language(code.Left.Language()).Build()
 		return result, nil
 	} else if message.autocode.Present() {
+		g.indexEnums(files)
 		autocode, err := g.generateFromMessage(files, message, nil)
 		if err != nil {
 			return nil, err
 		}
-		result := md.NewCodeblockBuilder().Text(autocode).Build()
+		result := md.NewCodeblockBuilder().Text(autocode).Language(SyntaxJson.Language()).Build()
 		return result, nil
 	} else {
 		return nil, fmt.Errorf("received message entry with both code and autocode flags missing")
@@ -71,12 +140,19 @@ func (g *Codegenerator) generateFromMessage(files []ParsedFile, message *Message
 	if js == nil {
 		js = make(map[string]any)
 	}
-	js["trx"] = uuid.NewString()
+	trx, err := uuid.NewRandomFromReader(g.rnd)
+	if err != nil {
+		return "", err
+	}
+	js["trx"] = trx.String()
 	js[message.m.GetName()] = map[string]any{}
 	jsMsg := js[message.m.GetName()].(map[string]any)
 	for _, field := range message.fields {
+		if field.deprecated.Present() && !g.includeDeprecated {
+			continue
+		}
 		if field.isMsg == nil {
-			value, err := g.generateFromField(files, field)
+			value, err := g.generateFromField(field)
 			if err != nil {
 				return "", err
 			}
@@ -90,94 +166,53 @@ func (g *Codegenerator) generateFromMessage(files []ParsedFile, message *Message
 	return string(res), err
 }
 
-func (g *Codegenerator) generateFromField(files []ParsedFile, field MessageField) (any, error) {
-	r := rand.New(rand.NewSource(time.Now().UnixNano() + rand.New(rand.NewSource(time.Now().UnixNano())).Int63()))
-	minVal := field.flags.OrElse(FieldFlags{}).GetMin()
-	maxVal := field.flags.OrElse(FieldFlags{}).GetMax()
-	maxLen := field.flags.OrElse(FieldFlags{}).GetMaxLength()
-	value := field.flags.OrElse(FieldFlags{}).GetValue()
-	customType := field.flags.OrElse(FieldFlags{}).GetCustomType()
-	field.valueType = customType.OrElse(field.ValueType())
-	switch field.valueType {
-	case ValueTypeInt:
-		if value.Present() {
-			return strconv.ParseInt(*value.Get(), 10, 64)
-		}
-		return int64WithinRange(r, int64(minVal.OrElse(0)), int64(maxVal.OrElse(1000000))), nil
-	case ValueTypeFloat:
-		if value.Present() {
-			return strconv.ParseFloat(*value.Get(), 64)
-		}
-		return minVal.OrElse(0.0) + r.Float64()*(maxVal.OrElse(1000000)-minVal.OrElse(0.0)), nil
-	case ValueTypeUInt:
-		if value.Present() {
-			return strconv.ParseUint(*value.Get(), 10, 64)
-		}
-		return uint64WithinRange(r, uint64(minVal.OrElse(0)), uint64(maxVal.OrElse(1000000))), nil
-	case ValueTypeEmail:
-		fallthrough
-	case ValueTypeString:
-		if value.Present() {
-			return value, nil
-		}
-		str := g.namegen.Generate()
-		if maxLen.Present() {
-			count := utf8.RuneCountInString(str)
-			if count > *maxLen.Get() {
-				return str[:*maxLen.Get()], nil
-			}
-		}
+func (g *Codegenerator) generateFromField(field MessageField) (any, error) {
+	flags := field.flags.OrElse(FieldFlags{})
+	valueType := flags.GetCustomType().OrElse(field.ValueType())
+	if valueType == ValueTypeStruct {
+		return nil, fmt.Errorf("cannot generate code from struct, you need to convert it to the field first")
+	}
 
-		if field.valueType == ValueTypeEmail {
-			return str + "@email.com", nil
-		}
+	generator, ok := g.generators[valueType]
+	if !ok {
+		return nil, fmt.Errorf("no value generator registered for type '%s': field '%s', message '%s'", valueType, field.d.GetName(), field.m.GetName())
+	}
 
-		return str, nil
-	case ValueTypePhone:
-		//+NNN.NNNNNNNNNN
-		phone := "+" + strconv.Itoa(int(int64WithinRange(r, 0, 1010)))
-		phone += "." + strconv.Itoa(int(int64WithinRange(r, 1000000000, 9999999999)))
-
-		return phone, nil
-	case ValueTypePassword:
-		return passGen.GetPassword(), nil
-	case ValueTypeUUID:
-		return uuid.NewString(), nil
-	case ValueTypeEnum:
-		var enum *Enum
-		for _, file := range files {
-			for _, entry := range file.entries {
-				if entry.enum != nil {
-					log.Info().Msgf("reading enum descriptor: % s", entry.enum.e.GetName())
-					if "."+entry.enum.e.GetFullName() == field.d.GetTypeName() {
-						enum = entry.enum
-					}
-					if enum != nil {
-						break
-					}
-				}
-			}
-		}
+	value, err := generator.Generate(field.d, flags, g.rnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate '%s' value for field '%s': %s", valueType, field.d.GetName(), err.Error())
+	}
 
-		if enum != nil {
-			values := enum.values
-			l := len(values)
+	return value, nil
+}
 
-			return values[rand.Intn(l)].d.GetName(), nil
+func (g *Codegenerator) indexEnums(files []ParsedFile) {
+	for _, file := range files {
+		for _, entry := range file.entries {
+			if entry.enum != nil {
+				log.Info().Msgf("reading enum descriptor: % s", entry.enum.e.GetName())
+				g.enums["."+entry.enum.e.GetFullName()] = entry.enum
+			}
 		}
-
-		return nil, nil
-	case ValueTypeStruct:
-		return nil, fmt.Errorf("cannot generate code from struct, you need to convert it to the field first")
-	default:
-		return nil, fmt.Errorf("unsupported value type received: field '%s', message '%s', package: %s", *field.d.Name, *field.d.Message.Name, field.d.Message.GetPackage())
 	}
 }
 
-func int64WithinRange(r *rand.Rand, min, max int64) int64 {
-	return min + r.Int63n(max)
-}
+func (g *Codegenerator) generateEnum(field *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return *value.Get(), nil
+	}
+
+	enum, ok := g.enums[field.GetTypeName()]
+	if !ok || len(enum.values) == 0 {
+		return nil, nil
+	}
+	values := enum.values
+	if !g.includeDeprecated {
+		// all values are used if every one of them is deprecated
+		if current := arrayutils.Filter(values, func(v *EnumField) bool { return !v.deprecated.Present() }); len(current) > 0 {
+			values = current
+		}
+	}
 
-func uint64WithinRange(r *rand.Rand, min, max uint64) uint64 {
-	return min + r.Uint64()*(max-min)
+	return values[r.Intn(len(values))].d.GetName(), nil
 }
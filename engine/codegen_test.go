@@ -1,10 +1,19 @@
 package engine
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/pseudomuto/protokit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
 	"gitlab.com/kordax/basic-utils/opt"
 	refutils "gitlab.com/kordax/basic-utils/ref-utils"
@@ -219,3 +228,206 @@ func createMockParsedFile() ParsedFile {
 		},
 	}
 }
+
+func TestCodegenerator_RegisterValueGenerator(t *testing.T) {
+	commonDescriptor := &protokit.Descriptor{
+		DescriptorProto: &descriptor.DescriptorProto{
+			Name: refutils.Ref("Account"),
+		},
+	}
+	fieldDescriptor := &protokit.FieldDescriptor{
+		FieldDescriptorProto: &descriptor.FieldDescriptorProto{
+			Name: refutils.Ref("iban"),
+		},
+	}
+	message := &Message{
+		m:        commonDescriptor,
+		autocode: opt.Of(AutocodeOpt{syntax: SyntaxJson}),
+		fields: []MessageField{
+			*NewMessageField(fieldDescriptor, commonDescriptor, "IBAN", ValueTypeString, &FieldFlags{customType: opt.Of(ValueType("iban"))}),
+		},
+	}
+
+	t.Run("unregistered type", func(t *testing.T) {
+		_, err := NewCodegenerator().Generate(nil, message)
+		assert.Error(t, err)
+	})
+
+	t.Run("registered type", func(t *testing.T) {
+		generator := NewCodegenerator()
+		generator.RegisterValueGenerator("IBAN", ValueGeneratorFunc(func(field *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+			return "DE89370400440532013000", nil
+		}))
+		code, err := generator.Generate(nil, message)
+		require.NoError(t, err)
+		assert.Contains(t, code.GetText(), "DE89370400440532013000")
+		assert.Equal(t, "json", code.GetLanguage())
+	})
+
+	t.Run("seeded output is stable", func(t *testing.T) {
+		for _, valueType := range []ValueType{ValueTypeString, ValueTypePassword, ValueTypeJWT} {
+			t.Run(string(valueType), func(t *testing.T) {
+				seededMessage := &Message{
+					m:        commonDescriptor,
+					autocode: opt.Of(AutocodeOpt{syntax: SyntaxJson}),
+					fields:   []MessageField{*NewMessageField(fieldDescriptor, commonDescriptor, "Value", valueType, nil)},
+				}
+				first, err := NewSeededCodegenerator(42).Generate(nil, seededMessage)
+				require.NoError(t, err)
+				second, err := NewSeededCodegenerator(42).Generate(nil, seededMessage)
+				require.NoError(t, err)
+				assert.Equal(t, first.GetText(), second.GetText())
+			})
+		}
+	})
+}
+
+func TestBuiltinValueGenerators(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name  string
+		t     ValueType
+		flags FieldFlags
+		check func(t *testing.T, value any)
+	}{
+		{
+			name:  "int within range",
+			t:     ValueTypeInt,
+			flags: FieldFlags{min: opt.Of(10.0), max: opt.Of(12.0)},
+			check: func(t *testing.T, value any) {
+				assert.GreaterOrEqual(t, value.(int64), int64(10))
+				assert.LessOrEqual(t, value.(int64), int64(12))
+			},
+		},
+		{
+			name:  "uint within range",
+			t:     ValueTypeUInt,
+			flags: FieldFlags{min: opt.Of(5.0), max: opt.Of(6.0)},
+			check: func(t *testing.T, value any) {
+				assert.GreaterOrEqual(t, value.(uint64), uint64(5))
+				assert.LessOrEqual(t, value.(uint64), uint64(6))
+			},
+		},
+		{
+			name:  "int wide range",
+			t:     ValueTypeInt,
+			flags: FieldFlags{min: opt.Of(-9e18), max: opt.Of(9e18)},
+			check: func(t *testing.T, value any) {
+				assert.GreaterOrEqual(t, value.(int64), int64(-9e18))
+				assert.LessOrEqual(t, value.(int64), int64(9e18))
+			},
+		},
+		{
+			name:  "int full range",
+			t:     ValueTypeInt,
+			flags: FieldFlags{min: opt.Of(-1e19), max: opt.Of(float64(math.MaxInt64))},
+			check: func(t *testing.T, value any) {
+				assert.IsType(t, int64(0), value)
+			},
+		},
+		{
+			name:  "uint max above int64",
+			t:     ValueTypeUInt,
+			flags: FieldFlags{max: opt.Of(1e19)},
+			check: func(t *testing.T, value any) {
+				assert.LessOrEqual(t, value.(uint64), uint64(1e19))
+			},
+		},
+		{
+			name:  "uint full range",
+			t:     ValueTypeUInt,
+			flags: FieldFlags{max: opt.Of(float64(math.MaxUint64))},
+			check: func(t *testing.T, value any) {
+				assert.IsType(t, uint64(0), value)
+			},
+		},
+		{
+			name:  "bool value",
+			t:     ValueTypeBool,
+			flags: FieldFlags{value: opt.Of("true")},
+			check: func(t *testing.T, value any) {
+				assert.Equal(t, true, value)
+			},
+		},
+		{
+			name:  "string value",
+			t:     ValueTypeString,
+			flags: FieldFlags{value: opt.Of("fixed")},
+			check: func(t *testing.T, value any) {
+				assert.Equal(t, "fixed", value)
+			},
+		},
+		{
+			name:  "string max length",
+			t:     ValueTypeString,
+			flags: FieldFlags{maxLength: opt.Of(3)},
+			check: func(t *testing.T, value any) {
+				assert.Len(t, value.(string), 3)
+			},
+		},
+		{
+			name: "uuid",
+			t:    ValueTypeUUID,
+			check: func(t *testing.T, value any) {
+				assert.Len(t, value.(string), 36)
+			},
+		},
+	}
+
+	generators := builtinValueGenerators()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := generators[tt.t].Generate(&protokit.FieldDescriptor{}, tt.flags, r)
+			require.NoError(t, err)
+			tt.check(t, value)
+		})
+	}
+}
+
+func TestWithinRange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		assert.GreaterOrEqual(t, int64WithinRange(r, math.MinInt64+1, math.MaxInt64), int64(math.MinInt64+1))
+		assert.GreaterOrEqual(t, int64WithinRange(r, -1, math.MaxInt64), int64(-1))
+		assert.LessOrEqual(t, uint64WithinRange(r, 0, math.MaxInt64), uint64(math.MaxInt64))
+		assert.GreaterOrEqual(t, uint64WithinRange(r, 1<<63, math.MaxUint64), uint64(1<<63))
+		assert.LessOrEqual(t, uint64WithinRange(r, 1, 1<<63+1), uint64(1<<63+1))
+	}
+	assert.Equal(t, int64(5), int64WithinRange(r, 5, 5))
+	assert.Equal(t, int64(math.MaxInt64), floatToInt64(math.MaxInt64))
+	assert.Equal(t, int64(math.MinInt64), floatToInt64(-1e19))
+	assert.Equal(t, uint64(0), floatToUint64(-1))
+}
+
+func TestGenerateJWT(t *testing.T) {
+	claims, err := parseClaims([]string{"claim=sub:alice", "claim=exp:+24h", "claim=admin:true", "len=10"})
+	require.NoError(t, err)
+	require.Len(t, claims, 3)
+
+	value, err := NewSeededCodegenerator(1).generateJWT(&protokit.FieldDescriptor{}, FieldFlags{claims: claims}, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	parts := strings.Split(value.(string), ".")
+	require.Len(t, parts, 3)
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"alg":"HS256","typ":"JWT"}`, string(header))
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(raw, &payload))
+	assert.Equal(t, "alice", payload["sub"])
+	assert.Equal(t, true, payload["admin"])
+	assert.Equal(t, float64(SeededExampleTime.Unix()), payload["iat"])
+	assert.Equal(t, float64(24*60*60), payload["exp"].(float64)-payload["iat"].(float64))
+
+	mac := hmac.New(sha256.New, []byte(JWTExampleKey))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), parts[2])
+
+	t.Run("invalid claim", func(t *testing.T) {
+		_, err := parseClaims([]string{"claim=sub"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,305 @@
+package engine
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+)
+
+type ChangeKind string
+
+const (
+	ChangeKindAdded   ChangeKind = "added"
+	ChangeKindRemoved ChangeKind = "removed"
+	ChangeKindChanged ChangeKind = "changed"
+)
+
+type ChangeElement string
+
+const (
+	ChangeElementMessage   ChangeElement = "message"
+	ChangeElementField     ChangeElement = "field"
+	ChangeElementEnum      ChangeElement = "enum"
+	ChangeElementEnumValue ChangeElement = "enum value"
+	ChangeElementService   ChangeElement = "service"
+	ChangeElementMethod    ChangeElement = "method"
+)
+
+// Change is a single difference between two API versions, Old and New are set for changed properties only
+type Change struct {
+	Kind     ChangeKind    `json:"kind"`
+	Element  ChangeElement `json:"element"`
+	Name     string        `json:"name"`
+	Property string        `json:"property,omitempty"`
+	Old      string        `json:"old,omitempty"`
+	New      string        `json:"new,omitempty"`
+}
+
+type DiffReport struct {
+	Changes []Change `json:"changes"`
+}
+
+func (r *DiffReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+/*
+Diff compares two versions of the API and reports added, removed and changed messages, fields, enums, enum values, services
+and methods. Fields are matched by name, so changed numbers and types are reported, and so are changed @min, @max, @len
+and @pattern constraints.
+*/
+func Diff(oldFiles, newFiles []ParsedFile) *DiffReport {
+	report := &DiffReport{Changes: make([]Change, 0)}
+	oldApi, newApi := newApiIndex(oldFiles), newApiIndex(newFiles)
+
+	for _, name := range unionKeys(oldApi.messages, newApi.messages) {
+		oldMsg, inOld := oldApi.messages[name]
+		newMsg, inNew := newApi.messages[name]
+		switch {
+		case !inOld:
+			report.add(ChangeKindAdded, ChangeElementMessage, name)
+		case !inNew:
+			report.add(ChangeKindRemoved, ChangeElementMessage, name)
+		default:
+			report.fields(name, oldMsg, newMsg)
+		}
+	}
+	for _, name := range unionKeys(oldApi.enums, newApi.enums) {
+		oldEnum, inOld := oldApi.enums[name]
+		newEnum, inNew := newApi.enums[name]
+		switch {
+		case !inOld:
+			report.add(ChangeKindAdded, ChangeElementEnum, name)
+		case !inNew:
+			report.add(ChangeKindRemoved, ChangeElementEnum, name)
+		default:
+			report.enumValues(name, oldEnum, newEnum)
+		}
+	}
+	for _, name := range unionKeys(oldApi.services, newApi.services) {
+		oldSvc, inOld := oldApi.services[name]
+		newSvc, inNew := newApi.services[name]
+		switch {
+		case !inOld:
+			report.add(ChangeKindAdded, ChangeElementService, name)
+		case !inNew:
+			report.add(ChangeKindRemoved, ChangeElementService, name)
+		default:
+			report.methods(name, oldSvc, newSvc)
+		}
+	}
+
+	return report
+}
+
+func (r *DiffReport) add(kind ChangeKind, element ChangeElement, name string) {
+	r.Changes = append(r.Changes, Change{Kind: kind, Element: element, Name: name})
+}
+
+func (r *DiffReport) change(element ChangeElement, name, property, oldValue, newValue string) {
+	if oldValue != newValue {
+		r.Changes = append(r.Changes, Change{Kind: ChangeKindChanged, Element: element, Name: name, Property: property, Old: oldValue, New: newValue})
+	}
+}
+
+func (r *DiffReport) fields(message string, oldMsg, newMsg *Message) {
+	oldFields, newFields := make(map[string]*MessageField), make(map[string]*MessageField)
+	for i := range oldMsg.fields {
+		oldFields[oldMsg.fields[i].d.GetName()] = &oldMsg.fields[i]
+	}
+	for i := range newMsg.fields {
+		newFields[newMsg.fields[i].d.GetName()] = &newMsg.fields[i]
+	}
+
+	for _, name := range unionKeys(oldFields, newFields) {
+		oldField, inOld := oldFields[name]
+		newField, inNew := newFields[name]
+		fullName := message + "." + name
+		switch {
+		case !inOld:
+			r.add(ChangeKindAdded, ChangeElementField, fullName)
+		case !inNew:
+			r.add(ChangeKindRemoved, ChangeElementField, fullName)
+		default:
+			r.change(ChangeElementField, fullName, "number", strconv.Itoa(int(oldField.d.GetNumber())), strconv.Itoa(int(newField.d.GetNumber())))
+			if !sameFieldType(oldField, newField) {
+				r.Changes = append(r.Changes, Change{Kind: ChangeKindChanged, Element: ChangeElementField, Name: fullName, Property: "type", Old: protoFieldType(oldField), New: protoFieldType(newField)})
+			}
+			oldFlags, newFlags := oldField.flags.OrElse(FieldFlags{}), newField.flags.OrElse(FieldFlags{})
+			r.change(ChangeElementField, fullName, "min", optString(oldFlags.min.Get(), formatFloat), optString(newFlags.min.Get(), formatFloat))
+			r.change(ChangeElementField, fullName, "max", optString(oldFlags.max.Get(), formatFloat), optString(newFlags.max.Get(), formatFloat))
+			r.change(ChangeElementField, fullName, "len", optString(oldFlags.maxLength.Get(), strconv.Itoa), optString(newFlags.maxLength.Get(), strconv.Itoa))
+			r.change(ChangeElementField, fullName, "pattern", optString(oldFlags.pattern.Get(), func(v string) string { return v }), optString(newFlags.pattern.Get(), func(v string) string { return v }))
+		}
+	}
+}
+
+func (r *DiffReport) enumValues(enum string, oldEnum, newEnum *Enum) {
+	oldValues, newValues := make(map[string]*EnumField), make(map[string]*EnumField)
+	for i := range oldEnum.values {
+		oldValues[oldEnum.values[i].d.GetName()] = &oldEnum.values[i]
+	}
+	for i := range newEnum.values {
+		newValues[newEnum.values[i].d.GetName()] = &newEnum.values[i]
+	}
+
+	for _, name := range unionKeys(oldValues, newValues) {
+		oldValue, inOld := oldValues[name]
+		newValue, inNew := newValues[name]
+		fullName := enum + "." + name
+		switch {
+		case !inOld:
+			r.add(ChangeKindAdded, ChangeElementEnumValue, fullName)
+		case !inNew:
+			r.add(ChangeKindRemoved, ChangeElementEnumValue, fullName)
+		default:
+			r.change(ChangeElementEnumValue, fullName, "number", strconv.Itoa(int(oldValue.d.GetNumber())), strconv.Itoa(int(newValue.d.GetNumber())))
+		}
+	}
+}
+
+func (r *DiffReport) methods(service string, oldSvc, newSvc *Service) {
+	oldMethods, newMethods := make(map[string]*ServiceMethod), make(map[string]*ServiceMethod)
+	for i := range oldSvc.methods {
+		oldMethods[oldSvc.methods[i].d.GetName()] = &oldSvc.methods[i]
+	}
+	for i := range newSvc.methods {
+		newMethods[newSvc.methods[i].d.GetName()] = &newSvc.methods[i]
+	}
+
+	for _, name := range unionKeys(oldMethods, newMethods) {
+		oldMethod, inOld := oldMethods[name]
+		newMethod, inNew := newMethods[name]
+		fullName := service + "." + name
+		switch {
+		case !inOld:
+			r.add(ChangeKindAdded, ChangeElementMethod, fullName)
+		case !inNew:
+			r.add(ChangeKindRemoved, ChangeElementMethod, fullName)
+		default:
+			r.change(ChangeElementMethod, fullName, "request", methodTypeString(oldMethod.d.GetInputType(), oldMethod.d.GetClientStreaming()), methodTypeString(newMethod.d.GetInputType(), newMethod.d.GetClientStreaming()))
+			r.change(ChangeElementMethod, fullName, "response", methodTypeString(oldMethod.d.GetOutputType(), oldMethod.d.GetServerStreaming()), methodTypeString(newMethod.d.GetOutputType(), newMethod.d.GetServerStreaming()))
+		}
+	}
+}
+
+/*
+Markdown returns the changelog document with 'Added', 'Removed' and 'Changed' sections, empty sections are omitted.
+*/
+func (r *DiffReport) Markdown() *md.Document {
+	document := md.NewDocumentBuilder().Build()
+	section := md.NewSectionBuilder().Build()
+	section.AddElement(MkHeader("API Changelog", md.HeaderLevelOne))
+	if len(r.Changes) == 0 {
+		section.AddElement(MkText("No changes.", md.TextEmphasisNormal))
+	}
+
+	for _, kind := range []ChangeKind{ChangeKindAdded, ChangeKindRemoved, ChangeKindChanged} {
+		list := MkList(false, nil)
+		for _, change := range r.Changes {
+			if change.Kind != kind {
+				continue
+			}
+			paragraph := MkParagraph()
+			// emphasized texts are followed by a space, normal ones are not
+			TextToParagraph(paragraph, string(change.Element)+" ", md.TextEmphasisNormal)
+			TextToParagraph(paragraph, change.Name, md.TextEmphasisCode)
+			if kind == ChangeKindChanged {
+				TextToParagraph(paragraph, change.Property+": ", md.TextEmphasisNormal)
+				changeValue(paragraph, change.Old)
+				TextToParagraph(paragraph, "→ ", md.TextEmphasisNormal)
+				changeValue(paragraph, change.New)
+			}
+			list.AddEntry(MkListEntry(list, paragraph))
+		}
+		if len(list.GetEntries()) == 0 {
+			continue
+		}
+
+		section.AddElement(MkHeader(strings.ToUpper(string(kind[:1]))+string(kind[1:]), md.HeaderLevelTwo))
+		section.AddElement(list)
+	}
+	document.AddSection(section)
+
+	return document
+}
+
+func changeValue(paragraph *md.Paragraph, value string) {
+	if value == "" {
+		TextToParagraph(paragraph, "none", md.TextEmphasisItalic)
+		return
+	}
+
+	TextToParagraph(paragraph, value, md.TextEmphasisCode)
+}
+
+// apiIndex holds all messages, including nested ones, enums and services of the API version by their full names
+type apiIndex struct {
+	messages map[string]*Message
+	enums    map[string]*Enum
+	services map[string]*Service
+}
+
+func newApiIndex(parsedFiles []ParsedFile) apiIndex {
+	index := apiIndex{messages: make(map[string]*Message), enums: make(map[string]*Enum), services: make(map[string]*Service)}
+	var entries func(entries []Entry)
+	entries = func(list []Entry) {
+		for _, entry := range list {
+			switch {
+			case entry.t == EntryTypeMessage && entry.msg.m != nil:
+				if !isMapEntry(entry.msg.m) {
+					index.messages[entry.msg.m.GetFullName()] = entry.msg
+				}
+				entries(entry.msg.entries)
+			case entry.t == EntryTypeEnum && entry.enum.e != nil:
+				index.enums[entry.enum.e.GetFullName()] = entry.enum
+			}
+		}
+	}
+	for _, file := range parsedFiles {
+		entries(file.entries)
+		for i := range file.services {
+			index.services[file.services[i].s.GetFullName()] = &file.services[i]
+		}
+	}
+
+	return index
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func methodTypeString(typeName string, stream bool) string {
+	if stream {
+		return "stream " + trimTypeName(typeName)
+	}
+
+	return trimTypeName(typeName)
+}
+
+func optString[T any](value *T, format func(v T) string) string {
+	if value == nil {
+		return ""
+	}
+
+	return format(*value)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// diffTestFiles parses the split test files as a descriptor set, changed by the modify function first
+func diffTestFiles(t *testing.T, modify func(common, orders *descriptorpb.FileDescriptorProto)) []ParsedFile {
+	request := splitTestRequest(t)
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range request.ProtoFile {
+		set.File = append(set.File, proto.Clone(file).(*descriptorpb.FileDescriptorProto))
+	}
+	if modify != nil {
+		modify(set.File[0], set.File[1])
+	}
+
+	files, err := NewDescriptorSetParser(set).Parse()
+	require.NoError(t, err)
+	return files
+}
+
+// tagsMap adds 'map<string, valueType> tags = 3' to shop.Order
+func tagsMap(orders *descriptorpb.FileDescriptorProto, valueType descriptorpb.FieldDescriptorProto_Type) {
+	order := orders.MessageType[0]
+	order.NestedType = append(order.NestedType, &descriptorpb.DescriptorProto{
+		Name: proto.String("TagsEntry"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{Name: proto.String("key"), Number: proto.Int32(1), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+			{Name: proto.String("value"), Number: proto.Int32(2), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: valueType.Enum()},
+		},
+		Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+	})
+	order.Field = append(order.Field, &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("tags"),
+		Number:   proto.Int32(3),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+		TypeName: proto.String(".shop.Order.TagsEntry"),
+	})
+}
+
+func TestDiff(t *testing.T) {
+	oldFiles := diffTestFiles(t, nil)
+
+	t.Run("no changes", func(t *testing.T) {
+		report := Diff(oldFiles, diffTestFiles(t, nil))
+		assert.Empty(t, report.Changes)
+	})
+
+	newFiles := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		money := common.MessageType[0]
+		money.Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+		money.Field[1].Number = proto.Int32(3)
+		common.EnumType[0].Value = append(common.EnumType[0].Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String("EUR"), Number: proto.Int32(1)})
+		// trailing comment of Money.amount
+		common.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{{
+			Path:             []int32{4, 0, 2, 0},
+			Span:             []int32{4, 2, 20},
+			TrailingComments: proto.String(" amount in cents @max=100 @len=12\n"),
+		}}}
+
+		orders.MessageType = orders.MessageType[:1]
+		orders.MessageType[0].Field = append(orders.MessageType[0].Field[:1], &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String("note"),
+			Number: proto.Int32(3),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+			Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		})
+	})
+	report := Diff(oldFiles, newFiles)
+
+	t.Run("changes", func(t *testing.T) {
+		assert.Equal(t, []Change{
+			{Kind: ChangeKindChanged, Element: ChangeElementField, Name: "common.Money.amount", Property: "type", Old: "int64", New: "string"},
+			{Kind: ChangeKindChanged, Element: ChangeElementField, Name: "common.Money.amount", Property: "max", New: "100"},
+			{Kind: ChangeKindChanged, Element: ChangeElementField, Name: "common.Money.amount", Property: "len", New: "12"},
+			{Kind: ChangeKindChanged, Element: ChangeElementField, Name: "common.Money.currency", Property: "number", Old: "2", New: "3"},
+			{Kind: ChangeKindRemoved, Element: ChangeElementMessage, Name: "shop.Item"},
+			{Kind: ChangeKindRemoved, Element: ChangeElementField, Name: "shop.Order.item"},
+			{Kind: ChangeKindAdded, Element: ChangeElementField, Name: "shop.Order.note"},
+			{Kind: ChangeKindAdded, Element: ChangeElementEnumValue, Name: "common.Currency.EUR"},
+		}, report.Changes)
+	})
+
+	t.Run("wire type", func(t *testing.T) {
+		files := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+			common.MessageType[0].Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_SINT64.Enum()
+			common.MessageType[0].Field[1].Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		})
+		assert.Equal(t, []Change{
+			{Kind: ChangeKindChanged, Element: ChangeElementField, Name: "common.Money.amount", Property: "type", Old: "int64", New: "sint64"},
+			{Kind: ChangeKindChanged, Element: ChangeElementField, Name: "common.Money.currency", Property: "type", Old: "common.Currency", New: "repeated common.Currency"},
+		}, Diff(oldFiles, files).Changes)
+	})
+
+	t.Run("map value type", func(t *testing.T) {
+		int32Tags := diffTestFiles(t, func(_, orders *descriptorpb.FileDescriptorProto) {
+			tagsMap(orders, descriptorpb.FieldDescriptorProto_TYPE_INT32)
+		})
+		assert.Empty(t, Diff(int32Tags, int32Tags).Changes)
+
+		stringTags := diffTestFiles(t, func(_, orders *descriptorpb.FileDescriptorProto) {
+			tagsMap(orders, descriptorpb.FieldDescriptorProto_TYPE_STRING)
+		})
+		assert.Equal(t, []Change{
+			{Kind: ChangeKindChanged, Element: ChangeElementField, Name: "shop.Order.tags", Property: "type", Old: "map<string, int32>", New: "map<string, string>"},
+		}, Diff(int32Tags, stringTags).Changes)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), report.Markdown())
+		require.NoError(t, err)
+		assert.Contains(t, result, "# API Changelog")
+		assert.Contains(t, result, "## Added\n\n* field `shop.Order.note`")
+		assert.Contains(t, result, "## Removed\n\n* message `shop.Item`")
+		assert.Contains(t, result, "* field `common.Money.amount` type: `int64` → `string`")
+		assert.Contains(t, result, "* field `common.Money.amount` max: *none* → `100`")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		content, err := report.JSON()
+		require.NoError(t, err)
+		var decoded DiffReport
+		require.NoError(t, json.Unmarshal(content, &decoded))
+		assert.Equal(t, report.Changes, decoded.Changes)
+		assert.Contains(t, string(content), `"element": "enum value"`)
+	})
+}
@@ -0,0 +1,370 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const wellKnownPackage = "google.protobuf"
+
+type DotClustering string
+
+const (
+	DotClusteringPackage DotClustering = "package"
+	DotClusteringFile    DotClustering = "file"
+)
+
+func ParseDotClustering(clustering string) (DotClustering, error) {
+	switch DotClustering(strings.ToLower(clustering)) {
+	case DotClusteringPackage:
+		return DotClusteringPackage, nil
+	case DotClusteringFile:
+		return DotClusteringFile, nil
+	}
+
+	return DotClusteringPackage, fmt.Errorf("unsupported clustering: '%s', supported values are: package, file", clustering)
+}
+
+type DotOptions struct {
+	Clustering DotClustering
+	// Root is the full name of the message, enum or service the graph starts from, all types are included if empty
+	Root string
+	// Depth limits the number of edges from the root, 0 means unlimited
+	Depth int
+	// HideWellKnown hides google.protobuf types and imports
+	HideWellKnown bool
+}
+
+type DotGraph struct {
+	Clusters []DotSubgraph
+	Nodes    []DotNode
+	Edges    []DotEdge
+}
+
+type DotSubgraph struct {
+	ID    string
+	Label string
+}
+
+type DotNode struct {
+	ID      string
+	Label   string
+	Shape   string
+	Style   string
+	Cluster string
+}
+
+type DotEdge struct {
+	From  string
+	To    string
+	Label string
+	Style string
+	// Tail and Head are the clusters of import edges, which are drawn between clusters instead of nodes
+	Tail string
+	Head string
+}
+
+type DotGenerator struct {
+	options DotOptions
+}
+
+func NewDotGenerator(options DotOptions) *DotGenerator {
+	return &DotGenerator{options: options}
+}
+
+/*
+Generate returns the dependency graph of all messages, enums and services. Field references and service methods are edges
+between the nodes, imports are edges between the clusters of the files.
+*/
+func (g *DotGenerator) Generate(parsedFiles []ParsedFile) (*DotGraph, error) {
+	b := newDotBuilder(g.options)
+	for _, file := range parsedFiles {
+		b.file(file)
+	}
+	for _, file := range parsedFiles {
+		b.references(file)
+	}
+
+	if g.options.Root != "" {
+		if _, ok := b.nodes[g.options.Root]; !ok {
+			return nil, fmt.Errorf("root type '%s' is not found", g.options.Root)
+		}
+		b.limit(g.options.Root, g.options.Depth)
+	}
+	for _, file := range parsedFiles {
+		b.imports(file)
+	}
+
+	return b.graph(), nil
+}
+
+type dotBuilder struct {
+	options  DotOptions
+	order    []string
+	nodes    map[string]*DotNode
+	edges    []DotEdge
+	clusters []DotSubgraph
+	// fileClusters maps file names to the cluster ids, messages maps full names to all messages including nested ones
+	fileClusters map[string]string
+	messages     map[string]*Message
+}
+
+func newDotBuilder(options DotOptions) *dotBuilder {
+	return &dotBuilder{
+		options:      options,
+		nodes:        make(map[string]*DotNode),
+		fileClusters: make(map[string]string),
+		messages:     make(map[string]*Message),
+	}
+}
+
+func (b *dotBuilder) cluster(label string) string {
+	for _, cluster := range b.clusters {
+		if cluster.Label == label {
+			return cluster.ID
+		}
+	}
+	id := fmt.Sprintf("cluster_%d", len(b.clusters))
+	b.clusters = append(b.clusters, DotSubgraph{ID: id, Label: label})
+
+	return id
+}
+
+func (b *dotBuilder) node(node DotNode) {
+	if _, ok := b.nodes[node.ID]; ok {
+		return
+	}
+	b.order = append(b.order, node.ID)
+	b.nodes[node.ID] = &node
+}
+
+func (b *dotBuilder) file(file ParsedFile) {
+	label := file.Package()
+	if b.options.Clustering == DotClusteringFile || label == "" {
+		label = file.Filename()
+	}
+	cluster := b.cluster(label)
+	b.fileClusters[file.Filename()] = cluster
+
+	var messages func(entries []Entry)
+	messages = func(entries []Entry) {
+		for _, entry := range entries {
+			switch {
+			case entry.t == EntryTypeMessage && entry.msg.m != nil:
+				b.messages[entry.msg.m.GetFullName()] = entry.msg
+				if !isMapEntry(entry.msg.m) {
+					b.node(DotNode{ID: entry.msg.m.GetFullName(), Label: entry.msg.m.GetName(), Shape: "box", Cluster: cluster})
+				}
+				messages(entry.msg.entries)
+			case entry.t == EntryTypeEnum && entry.enum.e != nil:
+				b.node(DotNode{ID: entry.enum.e.GetFullName(), Label: entry.enum.e.GetName(), Shape: "box", Style: "rounded", Cluster: cluster})
+			}
+		}
+	}
+	messages(file.entries)
+	for _, service := range file.services {
+		b.node(DotNode{ID: service.s.GetFullName(), Label: service.s.GetName(), Shape: "component", Cluster: cluster})
+	}
+}
+
+func (b *dotBuilder) references(file ParsedFile) {
+	var messages func(entries []Entry)
+	messages = func(entries []Entry) {
+		for _, entry := range entries {
+			if entry.t != EntryTypeMessage || entry.msg.m == nil {
+				continue
+			}
+			if !isMapEntry(entry.msg.m) {
+				for i := range entry.msg.fields {
+					b.field(entry.msg.m.GetFullName(), &entry.msg.fields[i])
+				}
+			}
+			messages(entry.msg.entries)
+		}
+	}
+	messages(file.entries)
+
+	for _, service := range file.services {
+		for _, method := range service.methods {
+			b.edge(service.s.GetFullName(), trimTypeName(method.d.GetInputType()), method.d.GetName(), "")
+			b.edge(service.s.GetFullName(), trimTypeName(method.d.GetOutputType()), method.d.GetName(), "dashed")
+		}
+	}
+}
+
+// field adds the edge to the field type, map fields refer to the type of their values
+func (b *dotBuilder) field(from string, field *MessageField) {
+	switch field.d.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+	default:
+		return
+	}
+
+	typeName := trimTypeName(field.d.GetTypeName())
+	if entry, ok := b.messages[typeName]; ok && isMapEntry(entry.m) {
+		for i := range entry.fields {
+			if entry.fields[i].d.GetNumber() == 2 {
+				b.field(from, &entry.fields[i])
+			}
+		}
+		return
+	}
+
+	style := ""
+	if field.d.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
+		style = "dashed"
+	}
+	b.edge(from, typeName, field.d.GetName(), style)
+}
+
+// edge adds the edge to the type, types which are not documented are added as external nodes of their package
+func (b *dotBuilder) edge(from, to, label, style string) {
+	if _, ok := b.nodes[to]; !ok {
+		pkg := ""
+		if i := strings.LastIndex(to, "."); i != -1 {
+			pkg = to[:i]
+		}
+		if b.options.HideWellKnown && pkg == wellKnownPackage {
+			return
+		}
+		node := DotNode{ID: to, Label: shortName(to), Shape: "box", Style: "dotted"}
+		if pkg != "" {
+			node.Cluster = b.cluster(pkg)
+		}
+		b.node(node)
+	}
+
+	b.edges = append(b.edges, DotEdge{From: from, To: to, Label: label, Style: style})
+}
+
+// limit keeps the nodes, which are reachable from the root within the depth, and the edges between them
+func (b *dotBuilder) limit(root string, depth int) {
+	reached := map[string]bool{root: true}
+	current := []string{root}
+	for level := 0; len(current) > 0 && (depth == 0 || level < depth); level++ {
+		var next []string
+		for _, edge := range b.edges {
+			if arrayutils.Contains(edge.From, current) != -1 && !reached[edge.To] {
+				reached[edge.To] = true
+				next = append(next, edge.To)
+			}
+		}
+		current = next
+	}
+
+	b.order = arrayutils.Filter(b.order, func(v *string) bool {
+		return reached[*v]
+	})
+	b.edges = arrayutils.Filter(b.edges, func(v *DotEdge) bool {
+		return reached[v.From] && reached[v.To]
+	})
+}
+
+// imports adds edges between the cluster of the file and the clusters of its imports, unless they are the same cluster
+func (b *dotBuilder) imports(file ParsedFile) {
+	tail := b.fileClusters[file.Filename()]
+	for _, dependency := range file.imports {
+		head, ok := b.fileClusters[dependency]
+		if !ok {
+			// imports of the files, which are not documented, are shown for well-known types only
+			if b.options.HideWellKnown || !strings.HasPrefix(dependency, "google/protobuf/") {
+				continue
+			}
+			head = b.cluster(wellKnownPackage)
+		}
+		if head == tail {
+			continue
+		}
+		from, to := b.clusterNode(tail), b.clusterNode(head)
+		if from == "" || to == "" {
+			continue
+		}
+		edge := DotEdge{From: from, To: to, Label: "import", Style: "dotted", Tail: tail, Head: head}
+		if arrayutils.Contains(edge, b.edges) == -1 {
+			b.edges = append(b.edges, edge)
+		}
+	}
+}
+
+// clusterNode returns the first node of the cluster, which import edges are attached to
+func (b *dotBuilder) clusterNode(cluster string) string {
+	for _, id := range b.order {
+		if b.nodes[id].Cluster == cluster {
+			return id
+		}
+	}
+
+	return ""
+}
+
+func (b *dotBuilder) graph() *DotGraph {
+	graph := &DotGraph{Edges: b.edges}
+	for _, id := range b.order {
+		graph.Nodes = append(graph.Nodes, *b.nodes[id])
+	}
+	for _, cluster := range b.clusters {
+		if b.clusterNode(cluster.ID) != "" {
+			graph.Clusters = append(graph.Clusters, cluster)
+		}
+	}
+
+	return graph
+}
+
+// String returns the graph in DOT language
+func (d *DotGraph) String() string {
+	var builder strings.Builder
+	builder.WriteString("digraph schema {\n")
+	builder.WriteString("    compound=true;\n")
+	builder.WriteString("    rankdir=LR;\n")
+	builder.WriteString("    node [fontname=\"Helvetica\"];\n")
+	builder.WriteString("    edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	nodes := func(cluster, indent string) {
+		for _, node := range d.Nodes {
+			if node.Cluster != cluster {
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("%s%s [label=%s, shape=%s", indent, dotQuote(node.ID), dotQuote(node.Label), node.Shape))
+			if node.Style != "" {
+				builder.WriteString(", style=" + node.Style)
+			}
+			builder.WriteString("];\n")
+		}
+	}
+	for _, cluster := range d.Clusters {
+		builder.WriteString(fmt.Sprintf("    subgraph %s {\n", dotQuote(cluster.ID)))
+		builder.WriteString(fmt.Sprintf("        label=%s;\n", dotQuote(cluster.Label)))
+		nodes(cluster.ID, "        ")
+		builder.WriteString("    }\n")
+	}
+	nodes("", "    ")
+
+	for _, edge := range d.Edges {
+		var attributes []string
+		if edge.Label != "" {
+			attributes = append(attributes, "label="+dotQuote(edge.Label))
+		}
+		if edge.Style != "" {
+			attributes = append(attributes, "style="+edge.Style)
+		}
+		if edge.Tail != "" {
+			attributes = append(attributes, "ltail="+dotQuote(edge.Tail), "lhead="+dotQuote(edge.Head))
+		}
+		builder.WriteString(fmt.Sprintf("    %s -> %s", dotQuote(edge.From), dotQuote(edge.To)))
+		if len(attributes) > 0 {
+			builder.WriteString(" [" + strings.Join(attributes, ", ") + "]")
+		}
+		builder.WriteString(";\n")
+	}
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+func dotQuote(value string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(value) + "\""
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func dotTestEntries(t *testing.T) []ParsedFile {
+	request := splitTestRequest(t)
+	orders := request.ProtoFile[1]
+	orders.Dependency = append(orders.Dependency, "google/protobuf/timestamp.proto")
+	orders.MessageType[0].Field = append(orders.MessageType[0].Field, &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("created_at"),
+		Number:   proto.Int32(3),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+		TypeName: proto.String(".google.protobuf.Timestamp"),
+	})
+
+	entries, err := NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+	return entries
+}
+
+func TestDotGenerator_Generate(t *testing.T) {
+	entries := dotTestEntries(t)
+
+	t.Run("clustered by package", func(t *testing.T) {
+		graph, err := NewDotGenerator(DotOptions{Clustering: DotClusteringPackage}).Generate(entries)
+		require.NoError(t, err)
+
+		result := graph.String()
+		assert.Contains(t, result, "    subgraph \"cluster_0\" {\n        label=\"common\";\n        \"common.Money\" [label=\"Money\", shape=box];\n")
+		assert.Contains(t, result, "\"common.Currency\" [label=\"Currency\", shape=box, style=rounded];")
+		assert.Contains(t, result, "\"google.protobuf.Timestamp\" [label=\"Timestamp\", shape=box, style=dotted];")
+		assert.Contains(t, result, "    \"shop.Order\" -> \"common.Money\" [label=\"price\"];\n")
+		assert.Contains(t, result, "    \"common.Money\" -> \"common.Currency\" [label=\"currency\", style=dashed];\n")
+		assert.Contains(t, result, "    \"shop.Order\" -> \"common.Money\" [label=\"import\", style=dotted, ltail=\"cluster_1\", lhead=\"cluster_0\"];\n")
+		assert.Contains(t, result, "[label=\"import\", style=dotted, ltail=\"cluster_1\", lhead=\"cluster_2\"]")
+	})
+
+	t.Run("clustered by file", func(t *testing.T) {
+		graph, err := NewDotGenerator(DotOptions{Clustering: DotClusteringFile}).Generate(entries)
+		require.NoError(t, err)
+		require.Len(t, graph.Clusters, 3)
+		assert.Equal(t, "api/common.proto", graph.Clusters[0].Label)
+		assert.Equal(t, "api/orders.proto", graph.Clusters[1].Label)
+	})
+
+	t.Run("well-known types hidden", func(t *testing.T) {
+		graph, err := NewDotGenerator(DotOptions{Clustering: DotClusteringPackage, HideWellKnown: true}).Generate(entries)
+		require.NoError(t, err)
+		assert.NotContains(t, graph.String(), "google.protobuf")
+		assert.Len(t, graph.Clusters, 2)
+	})
+
+	t.Run("root and depth", func(t *testing.T) {
+		graph, err := NewDotGenerator(DotOptions{Root: "shop.Order", Depth: 1, HideWellKnown: true}).Generate(entries)
+		require.NoError(t, err)
+		ids := make([]string, 0, len(graph.Nodes))
+		for _, node := range graph.Nodes {
+			ids = append(ids, node.ID)
+		}
+		assert.ElementsMatch(t, []string{"shop.Order", "shop.Item", "common.Money"}, ids)
+
+		graph, err = NewDotGenerator(DotOptions{Root: "shop.Order", HideWellKnown: true}).Generate(entries)
+		require.NoError(t, err)
+		assert.Len(t, graph.Nodes, 4)
+
+		_, err = NewDotGenerator(DotOptions{Root: "shop.Missing"}).Generate(entries)
+		assert.Error(t, err)
+	})
+}
+
+func TestDotGenerator_services(t *testing.T) {
+	request, err := testRequest()
+	require.NoError(t, err)
+	entries, err := NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+
+	graph, err := NewDotGenerator(DotOptions{Clustering: DotClusteringPackage}).Generate(entries)
+	require.NoError(t, err)
+	result := graph.String()
+	assert.Contains(t, result, "\"doc_generator_test.AuthService\" [label=\"AuthService\", shape=component];")
+	assert.Contains(t, result, "\"doc_generator_test.AuthService\" -> \"doc_generator_test.TokenRequest\" [label=\"Token\"];")
+	assert.Contains(t, result, "\"doc_generator_test.AuthService\" -> \"doc_generator_test.TokenResponse\" [label=\"Token\", style=dashed];")
+}
+
+func TestDotQuote(t *testing.T) {
+	assert.Equal(t, `"a\"b\\c"`, dotQuote(`a"b\c`))
+}
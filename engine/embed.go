@@ -4,3 +4,8 @@ import _ "embed"
 
 //go:embed test-proto/test.pb.desc
 var TestProtoDescriptor []byte
+
+// DefaultTemplate is the default layout of the template generator
+//
+//go:embed templates/default.md.tmpl
+var DefaultTemplate string
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// always escaped inline characters, '|' is escaped in table cells only
+const inlineMetacharacters = "\\`*[]<~"
+
+/*
+escapeInline escapes markdown metacharacters of the inline text, so it's rendered literally.
+Underscores are escaped at word boundaries only, since intraword underscores never start emphasis.
+Table cells additionally get '|' escaped and line breaks replaced with <br>.
+*/
+func escapeInline(text string, table bool) string {
+	runes := []rune(text)
+	var builder strings.Builder
+	lineStart := true
+	for i, ch := range runes {
+		switch {
+		case table && ch == '\n':
+			builder.WriteString("<br>")
+			continue
+		case table && ch == '|':
+			builder.WriteString("\\|")
+		case strings.ContainsRune(inlineMetacharacters, ch):
+			builder.WriteRune('\\')
+			builder.WriteRune(ch)
+		case ch == '_' && (!isWordRune(runes, i-1) || !isWordRune(runes, i+1)):
+			builder.WriteString("\\_")
+		case ch == '&' && isEntity(runes[i+1:]):
+			builder.WriteString("\\&")
+		case !table && (lineStart && isBlockMarker(runes, i) || isOrderedListDot(runes, i)):
+			builder.WriteRune('\\')
+			builder.WriteRune(ch)
+		default:
+			builder.WriteRune(ch)
+		}
+		lineStart = ch == '\n' || (lineStart && ch == ' ')
+	}
+
+	return builder.String()
+}
+
+/*
+escapeCodeSpan wraps the text with a backtick fence, which is longer than any backtick run of the text.
+*/
+func escapeCodeSpan(text string, table bool) string {
+	if table {
+		text = strings.ReplaceAll(text, "|", "\\|")
+	}
+	longest, run := 0, 0
+	for _, ch := range text {
+		if ch == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+
+	return fence + text + fence
+}
+
+var urlReplacer = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E", "\n", "")
+
+// escapeUrl percent-encodes characters that terminate or break link destinations.
+func escapeUrl(url string, table bool) string {
+	url = urlReplacer.Replace(url)
+	if table {
+		url = strings.ReplaceAll(url, "|", "%7C")
+	}
+
+	return url
+}
+
+func escapeLinkTitle(title string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(title, "\\", "\\\\"), "\"", "\\\"")
+}
+
+func escapeAnchor(name string) string {
+	return html.EscapeString(name)
+}
+
+func isWordRune(runes []rune, i int) bool {
+	if i < 0 || i >= len(runes) {
+		return false
+	}
+
+	return unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])
+}
+
+// isEntity reports whether text following '&' would be read as an HTML entity, e.g. '&amp;' or '&#35;'
+func isEntity(runes []rune) bool {
+	for i, ch := range runes {
+		if ch == ';' {
+			return i > 0
+		}
+		if !(unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '#') || i > 32 {
+			return false
+		}
+	}
+
+	return false
+}
+
+// isBlockMarker reports whether the rune starts a heading, a blockquote, a bullet or a thematic break
+func isBlockMarker(runes []rune, i int) bool {
+	switch runes[i] {
+	case '#', '>':
+		return true
+	case '-', '+', '=':
+		return i+1 >= len(runes) || runes[i+1] == ' ' || runes[i+1] == runes[i]
+	}
+
+	return false
+}
+
+// isOrderedListDot reports whether the rune is a dot of an ordered list marker like '1. ' at the line start
+func isOrderedListDot(runes []rune, i int) bool {
+	if runes[i] != '.' && runes[i] != ')' || i == 0 || (i+1 < len(runes) && runes[i+1] != ' ') {
+		return false
+	}
+	j := i - 1
+	for j >= 0 && unicode.IsDigit(runes[j]) {
+		j--
+	}
+	if j == i-1 {
+		return false
+	}
+	for j >= 0 && runes[j] == ' ' {
+		j--
+	}
+
+	return j < 0 || runes[j] == '\n'
+}
@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+)
+
+/*
+FilterOptions select the documented files by glob patterns of their names, e.g. 'billing/**' or 'api/*.proto', and messages,
+enums and services by regular expressions of their full names, e.g. 'billing\.v1\..*'. Empty include patterns select everything,
+exclude patterns are applied after them.
+*/
+type FilterOptions struct {
+	IncludeFiles []string
+	ExcludeFiles []string
+	IncludeNames []string
+	ExcludeNames []string
+}
+
+type nameFilter struct {
+	include, exclude []*regexp.Regexp
+}
+
+/*
+Filter returns the files and their top level messages, enums and services selected by the options, nested messages are kept
+with their parents. The parsed files are not modified.
+*/
+func Filter(parsedFiles []ParsedFile, options FilterOptions) ([]ParsedFile, error) {
+	files, err := newNameFilter(options.IncludeFiles, options.ExcludeFiles, globPattern)
+	if err != nil {
+		return nil, err
+	}
+	names, err := newNameFilter(options.IncludeNames, options.ExcludeNames, func(pattern string) (*regexp.Regexp, error) {
+		return regexp.Compile("^(?:" + pattern + ")$")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ParsedFile, 0, len(parsedFiles))
+	for _, file := range parsedFiles {
+		if !files.matches(file.filename) {
+			log.Info().Msgf("file '%s' is filtered out", file.filename)
+			continue
+		}
+		file.entries = arrayutils.Filter(file.entries, func(v *Entry) bool {
+			switch {
+			case v.t == EntryTypeMessage && v.msg != nil && v.msg.m != nil:
+				return names.matches(v.msg.m.GetFullName())
+			case v.t == EntryTypeEnum && v.enum != nil && v.enum.e != nil:
+				return names.matches(v.enum.e.GetFullName())
+			default:
+				return true
+			}
+		})
+		file.services = arrayutils.Filter(file.services, func(v *Service) bool {
+			return names.matches(v.s.GetFullName())
+		})
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+func newNameFilter(include, exclude []string, compile func(pattern string) (*regexp.Regexp, error)) (*nameFilter, error) {
+	filter := &nameFilter{}
+	for _, pattern := range include {
+		re, err := compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern '%s': %s", pattern, err.Error())
+		}
+		filter.include = append(filter.include, re)
+	}
+	for _, pattern := range exclude {
+		re, err := compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern '%s': %s", pattern, err.Error())
+		}
+		filter.exclude = append(filter.exclude, re)
+	}
+
+	return filter, nil
+}
+
+func (f *nameFilter) matches(name string) bool {
+	matches := func(v **regexp.Regexp) bool {
+		return (*v).MatchString(name)
+	}
+	if len(f.include) > 0 {
+		if _, found := arrayutils.ContainsPredicate(f.include, matches); found == nil {
+			return false
+		}
+	}
+	_, found := arrayutils.ContainsPredicate(f.exclude, matches)
+
+	return found == nil
+}
+
+/*
+globPattern converts the file glob to the regular expression. '*' and '?' match within a path segment, '**' matches any number
+of segments, e.g. 'api/**' matches every file under 'api'.
+*/
+func globPattern(glob string) (*regexp.Regexp, error) {
+	var builder strings.Builder
+	builder.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			builder.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			builder.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			builder.WriteString("[^/]*")
+		case glob[i] == '?':
+			builder.WriteString("[^/]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	builder.WriteString("$")
+
+	return regexp.Compile(builder.String())
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	files := diffTestFiles(t, nil)
+
+	tests := []struct {
+		name     string
+		options  FilterOptions
+		messages []string
+		enums    []string
+	}{
+		{"no filters", FilterOptions{}, []string{"common.Money", "shop.Item", "shop.Order"}, []string{"common.Currency"}},
+		{"include files", FilterOptions{IncludeFiles: []string{"api/orders.*"}}, []string{"shop.Item", "shop.Order"}, []string{}},
+		{"exclude files", FilterOptions{ExcludeFiles: []string{"**/orders.proto"}}, []string{"common.Money"}, []string{"common.Currency"}},
+		{"include names", FilterOptions{IncludeNames: []string{`shop\..*`, "common.Currency"}}, []string{"shop.Item", "shop.Order"}, []string{"common.Currency"}},
+		{"exclude names", FilterOptions{IncludeFiles: []string{"api/**"}, ExcludeNames: []string{".*Item"}}, []string{"common.Money", "shop.Order"}, []string{"common.Currency"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := Filter(files, tt.options)
+			require.NoError(t, err)
+			api := newApiIndex(filtered)
+			assert.Equal(t, tt.messages, unionKeys(api.messages, nil))
+			assert.Equal(t, tt.enums, unionKeys(api.enums, nil))
+		})
+	}
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := Filter(files, FilterOptions{ExcludeNames: []string{"shop.(Item"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestGlobPattern(t *testing.T) {
+	tests := []struct {
+		glob  string
+		name  string
+		match bool
+	}{
+		{"*.proto", "orders.proto", true},
+		{"*.proto", "api/orders.proto", false},
+		{"api/**", "api/v1/orders.proto", true},
+		{"**/orders.proto", "orders.proto", true},
+		{"**/orders.proto", "api/v1/orders.proto", true},
+		{"api/v?/*.proto", "api/v1/orders.proto", true},
+		{"api/v?/*.proto", "api/v10/orders.proto", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.name, func(t *testing.T) {
+			re, err := globPattern(tt.glob)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, re.MatchString(tt.name))
+		})
+	}
+}
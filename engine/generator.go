@@ -9,25 +9,144 @@ import (
 	"github.com/kordax/pb-md5-generator/engine/md"
 	"github.com/pseudomuto/protokit"
 	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+	"gitlab.com/kordax/basic-utils/opt"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 type Generator[R any] interface {
-	Generate(messages []Message) (*R, error)
+	Generate(parsedFiles []ParsedFile) (*R, error)
 }
 
 type MDGenerator struct {
-	codegen *Codegenerator
+	codegen        *Codegenerator
+	prefix         *md.Document
+	prefixSource   string
+	diagrams       DiagramMode
+	hideDeprecated bool
+	sinceBadges    bool
+	headings       Headings
+}
+
+// Headings are the texts of the generated headings and labels, which aren't taken from the protos
+type Headings struct {
+	TableOfContents string `yaml:"table_of_contents"`
+	Enums           string `yaml:"enums"`
+	APIDescription  string `yaml:"api_description"`
+	Services        string `yaml:"services"`
+	ScalarTypes     string `yaml:"scalar_types"`
+	UsedBy          string `yaml:"used_by"`
+	Response        string `yaml:"response"`
+}
+
+func DefaultHeadings() Headings {
+	return Headings{
+		TableOfContents: "Table Of Contents",
+		Enums:           "Enums",
+		APIDescription:  "API Description",
+		Services:        "Services",
+		ScalarTypes:     "Scalar Value Types",
+		UsedBy:          "Used by:",
+		Response:        "Response:",
+	}
 }
 
 func NewMDGenerator(codegen *Codegenerator) *MDGenerator {
-	return &MDGenerator{codegen: codegen}
+	return &MDGenerator{codegen: codegen, headings: DefaultHeadings()}
+}
+
+// SetHeadings sets the heading texts, empty ones keep their defaults
+func (g *MDGenerator) SetHeadings(headings Headings) {
+	g.headings = headingsOrDefaults(headings)
+}
+
+func headingsOrDefaults(headings Headings) Headings {
+	defaults := DefaultHeadings()
+	for _, heading := range []struct{ value, fallback *string }{
+		{&headings.TableOfContents, &defaults.TableOfContents},
+		{&headings.Enums, &defaults.Enums},
+		{&headings.APIDescription, &defaults.APIDescription},
+		{&headings.Services, &defaults.Services},
+		{&headings.ScalarTypes, &defaults.ScalarTypes},
+		{&headings.UsedBy, &defaults.UsedBy},
+		{&headings.Response, &defaults.Response},
+	} {
+		if *heading.value == "" {
+			*heading.value = *heading.fallback
+		}
+	}
+
+	return headings
+}
+
+/*
+SetPrefix sets the markdown document placed before the generated sections as is. It's parsed only to list its headings in the
+table of contents, an unclosed code block is reported as an error.
+*/
+func (g *MDGenerator) SetPrefix(source string) error {
+	prefix, err := md.Parse(source)
+	if err != nil {
+		return err
+	}
+	g.prefix = prefix
+	g.prefixSource = source
+
+	return nil
+}
+
+// SetHideDeprecated hides deprecated messages and enums from the table of contents, they are still documented
+func (g *MDGenerator) SetHideDeprecated(hide bool) {
+	g.hideDeprecated = hide
+}
+
+// SetSinceBadges adds 'new in <version>' badges of @since versions to the table of contents entries
+func (g *MDGenerator) SetSinceBadges(badges bool) {
+	g.sinceBadges = badges
 }
 
 func (g *MDGenerator) Generate(parsedFiles []ParsedFile) (*md.Document, error) {
-	tocSection := md.NewSectionBuilder().Build()
+	tocSection := md.NewSectionBuilder().Name(RegionTableOfContents).Build()
 	result := &md.Document{}
+	allEntries, enums := collectEntries(parsedFiles)
+	refs := buildReferenceIndex(parsedFiles)
+
+	if g.prefix != nil {
+		result.AddSection(prefixSection(g.prefixSource))
+	}
+
+	g.tableOfContents(allEntries, enums, tocSection)
+	result.AddSection(tocSection)
+
+	sortedFiles := parsedFiles
+	sort.Slice(sortedFiles, func(i, j int) bool {
+		return sortedFiles[i].index < sortedFiles[j].index
+	})
+
+	for _, parsedFile := range parsedFiles {
+		section, err := g.fileSection(parsedFiles, parsedFile, refs)
+		if err != nil {
+			return nil, err
+		}
+		result.AddSection(section)
+	}
 
+	enumSection, err := g.enumSection(enums, refs)
+	if err != nil {
+		return nil, err
+	}
+	result.AddSection(enumSection)
+
+	links := newLinkResolver(parsedFiles, func(ParsedFile) string {
+		return ""
+	})
+	if scalars := links.resolve(result, ""); len(scalars) > 0 {
+		result.AddSection(scalarSection(scalars, g.headings.ScalarTypes))
+	}
+
+	return result, nil
+}
+
+// collectEntries returns messages and enums of all files, ordered by their index, and the enums separately
+func collectEntries(parsedFiles []ParsedFile) ([]Entry, []Entry) {
 	collectedEntries := arrayutils.MapAggr(parsedFiles, func(v *ParsedFile) []Entry {
 		return v.entries
 	})
@@ -44,65 +163,70 @@ func (g *MDGenerator) Generate(parsedFiles []ParsedFile) (*md.Document, error) {
 		return enums[i].index < enums[j].index
 	})
 
-	g.tableOfContents(allEntries, enums, tocSection)
-	result.AddSection(tocSection)
+	return allEntries, enums
+}
 
-	sortedFiles := parsedFiles
-	sort.Slice(sortedFiles, func(i, j int) bool {
-		return sortedFiles[i].index < sortedFiles[j].index
+func (g *MDGenerator) fileSection(parsedFiles []ParsedFile, parsedFile ParsedFile, refs referenceIndex) (*md.Section, error) {
+	entries := parsedFile.entries
+	section := md.NewSectionBuilder().Name(parsedFile.Filename()).Build()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].index < entries[j].index
 	})
 
-	for _, parsedFile := range parsedFiles {
-		entries := parsedFile.entries
-		section := md.NewSectionBuilder().Build()
-		sort.SliceStable(entries, func(i, j int) bool {
-			return entries[i].index < entries[j].index
-		})
+	if parsedFile.Title() != "" {
+		g.header(parsedFile.Title(), 1, section)
+	} else {
+		g.header(parsedFile.Filename(), 1, section)
+	}
+	g.header(g.headings.APIDescription, 2, section)
+	if g.diagrams == DiagramModeFile {
+		g.diagram(parsedFiles, groupMessages(entries, func(*Message) bool { return true }), section)
+	}
 
-		if parsedFile.Title() != "" {
-			g.header(parsedFile.Title(), 1, section)
-		} else {
-			g.header(parsedFile.Filename(), 1, section)
-		}
-		g.header("API Description", 2, section)
-
-		header := ""
-		for _, entry := range entries {
-			switch entry.t {
-			case EntryTypeMessage:
-				if entry.msg.header != header {
-					g.header(entry.msg.header, 3, section)
-				}
-				header = entry.msg.header
-				if entry.msg.m != nil {
-					err := g.message(parsedFiles, entry.msg, section)
-					if err != nil {
-						return nil, err
-					}
+	header := ""
+	grouped := false
+	for i, entry := range entries {
+		switch entry.t {
+		case EntryTypeMessage:
+			if entry.msg.header != header {
+				g.header(entry.msg.header, 3, section)
+			}
+			if g.diagrams == DiagramModeHeader && (!grouped || entry.msg.header != header) {
+				group := entry.msg.header
+				g.diagram(parsedFiles, groupMessages(entries[i:], func(m *Message) bool { return m.header == group }), section)
+				grouped = true
+			}
+			header = entry.msg.header
+			if entry.msg.m != nil {
+				err := g.message(parsedFiles, entry.msg, section, refs)
+				if err != nil {
+					return nil, err
 				}
 			}
 		}
-
-		result.AddSection(section)
 	}
+	g.services(parsedFile, section)
+
+	return section, nil
+}
 
-	enumSection := md.NewSectionBuilder().Build()
-	g.header("Enums", 2, enumSection)
+func (g *MDGenerator) enumSection(enums []Entry, refs referenceIndex) (*md.Section, error) {
+	enumSection := md.NewSectionBuilder().Name(RegionEnums).Build()
+	g.header(g.headings.Enums, 2, enumSection)
 	for _, enum := range enums {
 		if enum.enum.e != nil {
-			err := g.enum(enum.enum, enumSection)
+			err := g.enum(enum.enum, enumSection, refs)
 			if err != nil {
 				return nil, err
 			}
 		}
 	}
-	result.AddSection(enumSection)
 
-	return result, nil
+	return enumSection, nil
 }
 
 func (g *MDGenerator) tableOfContents(entries []Entry, enums []Entry, section *md.Section) {
-	messages := arrayutils.Filter(entries, func(v *Entry) bool {
+	messages := arrayutils.Filter(g.tocEntries(entries), func(v *Entry) bool {
 		return v.t == EntryTypeMessage
 	})
 	sort.Slice(messages, func(i, j int) bool {
@@ -110,29 +234,45 @@ func (g *MDGenerator) tableOfContents(entries []Entry, enums []Entry, section *m
 	})
 
 	toc := MkList(false, nil)
-	entry := MkListTextEntry(toc, "Table Of Contents")
+	entry := MkListTextEntry(toc, g.headings.TableOfContents)
+	if g.prefix != nil {
+		if headings := prefixHeadings(g.prefix); len(headings) > 0 {
+			entry.AddSublist(headingListRecursive(headings, MkList(false, toc)))
+		}
+	}
 	result := g.list(messages, toc, false, 0)
 	entry.AddSublist(result)
 	toc.AddEntry(entry)
 	section.AddElement(toc)
 
 	tocEnums := MkList(false, nil)
-	entry = MkListTextEntry(tocEnums, "Enums")
-	result = g.list(enums, tocEnums, false, 0)
+	entry = MkListTextEntry(tocEnums, g.headings.Enums)
+	result = g.list(g.tocEntries(enums), tocEnums, false, 0)
 	entry.AddSublist(result)
 	tocEnums.AddEntry(entry)
 	section.AddElement(tocEnums)
 }
 
+// tocEntries returns the entries listed in the table of contents
+func (g *MDGenerator) tocEntries(entries []Entry) []Entry {
+	if !g.hideDeprecated {
+		return entries
+	}
+
+	return arrayutils.Filter(entries, func(v *Entry) bool {
+		return !(v.t == EntryTypeMessage && v.msg.deprecated.Present()) && !(v.t == EntryTypeEnum && v.enum.deprecated.Present())
+	})
+}
+
 func (g *MDGenerator) header(header string, level md.HeaderLevel, section *md.Section) {
 	section.AddElement(md.NewHeaderBuilder().Text(header).Level(level).Build())
 }
 
 func (g *MDGenerator) list(entries []Entry, parent *md.List, ordered bool, levels int) *md.List {
-	return listRecursive(entries, ordered, parent, 0, levels)
+	return listRecursive(entries, ordered, parent, 0, levels, g.sinceBadges)
 }
 
-func (g *MDGenerator) message(files []ParsedFile, message *Message, section *md.Section) error {
+func (g *MDGenerator) message(files []ParsedFile, message *Message, section *md.Section, refs referenceIndex) error {
 	section.AddElement(MkMessageRef(message))
 	name := message.m.GetFullName()
 	if name == "" {
@@ -147,25 +287,31 @@ func (g *MDGenerator) message(files []ParsedFile, message *Message, section *md.
 		text = name + " message:"
 		g.header(text, 4, section)
 	}
+	deprecation(message.deprecated, section)
+	since(message.since, section)
 
 	colField := md.NewColumnBuilder().Name("Field").Build()
 	colType := md.NewColumnBuilder().Name("Type").Build()
 	colLabel := md.NewColumnBuilder().Name("Label").Build()
 	colDesc := md.NewColumnBuilder().Name("Description").Build()
-	colMin := md.NewColumnBuilder().Name("Min value").Build()
-	colMax := md.NewColumnBuilder().Name("Max value").Build()
-	colLen := md.NewColumnBuilder().Name("Max length/size").Build()
+	colMin := md.NewColumnBuilder().Name("Min value").Alignment(md.ColumnAlignmentRight).Build()
+	colMax := md.NewColumnBuilder().Name("Max value").Alignment(md.ColumnAlignmentRight).Build()
+	colLen := md.NewColumnBuilder().Name("Max length/size").Alignment(md.ColumnAlignmentRight).Build()
+	colPattern := md.NewColumnBuilder().Name("Pattern").Build()
+	colSince := md.NewColumnBuilder().Name("Since").Build()
 
 	minFound := false
 	maxFound := false
 	lenFound := false
+	patternFound := false
+	sinceFound := false
 	for _, field := range message.fields {
 		fRow := MkRow()
-		fRow.AddText(MkText(field.d.GetName(), md.TextEmphasisBold))
+		fRow.AddText(MkText(field.d.GetName(), nameEmphasis(field.deprecated)))
 		colField.AddRow(fRow)
 
 		tRow := MkRow()
-		tRow.AddLink(MkFieldTypeLink(&field))
+		tRow.AddLink(MkFieldTypeLink(&field, message.m.GetPackage()))
 		colType.AddRow(tRow)
 
 		lRow := MkRow()
@@ -173,34 +319,47 @@ func (g *MDGenerator) message(files []ParsedFile, message *Message, section *md.
 		colLabel.AddRow(lRow)
 
 		dRow := MkRow()
+		field.deprecated.IfPresent(func(reason string) {
+			dRow.AddText(MkText(deprecationNote(reason), md.TextEmphasisItalic))
+		})
 		dRow.AddText(MkText(field.description, md.TextEmphasisNormal))
 		colDesc.AddRow(dRow)
 
-		if field.flags.Present() {
-			flags := field.flags.Get()
-			flags.min.IfPresent(func(min float64) {
-				minFound = true
-				row := MkRow()
-				row.AddText(MkText(strconv.FormatFloat(min, 'f', -1, 64), md.TextEmphasisNormal))
-				colMin.AddRow(row)
-			})
-			flags.max.IfPresent(func(max float64) {
-				maxFound = true
-				row := MkRow()
-				row.AddText(MkText(strconv.FormatFloat(max, 'f', -1, 64), md.TextEmphasisNormal))
-				colMax.AddRow(row)
-			})
-			flags.maxLength.IfPresent(func(max int) {
-				lenFound = true
-				row := MkRow()
-				row.AddText(MkText(strconv.Itoa(max), md.TextEmphasisNormal))
-				colLen.AddRow(row)
-			})
-		} else {
-			colMin.AddRow(MkRow())
-			colMax.AddRow(MkRow())
-			colLen.AddRow(MkRow())
+		flags := field.flags.OrElse(FieldFlags{})
+		minRow := MkRow()
+		flags.min.IfPresent(func(min float64) {
+			minFound = true
+			minRow.AddText(MkText(strconv.FormatFloat(min, 'f', -1, 64), md.TextEmphasisNormal))
+		})
+		colMin.AddRow(minRow)
+
+		maxRow := MkRow()
+		flags.max.IfPresent(func(max float64) {
+			maxFound = true
+			maxRow.AddText(MkText(strconv.FormatFloat(max, 'f', -1, 64), md.TextEmphasisNormal))
+		})
+		colMax.AddRow(maxRow)
+
+		lenRow := MkRow()
+		flags.maxLength.IfPresent(func(max int) {
+			lenFound = true
+			lenRow.AddText(MkText(strconv.Itoa(max), md.TextEmphasisNormal))
+		})
+		colLen.AddRow(lenRow)
+
+		pRow := MkRow()
+		flags.pattern.IfPresent(func(pattern string) {
+			patternFound = true
+			pRow.AddText(MkText(pattern, md.TextEmphasisCode))
+		})
+		colPattern.AddRow(pRow)
+
+		sRow := MkRow()
+		if field.since != "" {
+			sinceFound = true
+			sRow.AddText(MkText(field.since, md.TextEmphasisNormal))
 		}
+		colSince.AddRow(sRow)
 	}
 
 	table := md.NewTableBuilder().Rows(len(message.fields)).Build()
@@ -219,12 +378,20 @@ func (g *MDGenerator) message(files []ParsedFile, message *Message, section *md.
 	if lenFound {
 		table.AddColumn(colLen)
 	}
+	if patternFound {
+		table.AddColumn(colPattern)
+	}
+	if sinceFound {
+		table.AddColumn(colSince)
+	}
 
 	section.AddElement(table)
+	g.response(files, message, section)
+	g.usedBy(name, refs, section)
 
 	message.code.IfPresent(func(code arrayutils.Pair[Syntax, string]) {
 		g.header(fmt.Sprintf("'%s' code example:", message.m.GetName()), 4, section)
-		g.code(code.Right, section)
+		g.code(code.Right, code.Left, section)
 	})
 	message.autocode.IfPresent(func(ac AutocodeOpt) {
 		g.header(fmt.Sprintf("'%s' code example:", message.m.GetName()), 4, section)
@@ -238,7 +405,7 @@ func (g *MDGenerator) message(files []ParsedFile, message *Message, section *md.
 	return nil
 }
 
-func (g *MDGenerator) enum(enum *Enum, section *md.Section) error {
+func (g *MDGenerator) enum(enum *Enum, section *md.Section, refs referenceIndex) error {
 	section.AddElement(MkEnumRef(enum))
 	name := enum.e.GetFullName()
 	if name == "" {
@@ -253,31 +420,80 @@ func (g *MDGenerator) enum(enum *Enum, section *md.Section) error {
 		text = name + ":"
 		g.header(text, 4, section)
 	}
-	table := md.NewTableBuilder().Rows(len(enum.e.GetValues())).Build()
+	deprecation(enum.deprecated, section)
+	since(enum.since, section)
+	table := md.NewTableBuilder().Rows(len(enum.values)).Build()
 
 	colField := md.NewColumnBuilder().Name("Value").Build()
 	colDesc := md.NewColumnBuilder().Name("Description").Build()
+	colSince := md.NewColumnBuilder().Name("Since").Build()
+	sinceFound := false
 
 	for _, value := range enum.values {
 		fRow := MkRow()
-		fRow.AddText(MkText(value.d.GetName(), md.TextEmphasisBold))
+		fRow.AddText(MkText(value.d.GetName(), nameEmphasis(value.deprecated)))
 		colField.AddRow(fRow)
 
 		dRow := MkRow()
+		value.deprecated.IfPresent(func(reason string) {
+			dRow.AddText(MkText(deprecationNote(reason), md.TextEmphasisItalic))
+		})
 		dRow.AddText(MkText(value.description, md.TextEmphasisNormal))
 		colDesc.AddRow(dRow)
+
+		sRow := MkRow()
+		if value.since != "" {
+			sinceFound = true
+			sRow.AddText(MkText(value.since, md.TextEmphasisNormal))
+		}
+		colSince.AddRow(sRow)
 	}
 
 	table.AddColumn(colField)
 	table.AddColumn(colDesc)
+	if sinceFound {
+		table.AddColumn(colSince)
+	}
 
 	section.AddElement(table)
+	g.usedBy(name, refs, section)
 
 	return nil
 }
 
-func (g *MDGenerator) code(code string, section *md.Section) {
-	section.AddElement(md.NewCodeblockBuilder().Text(code).Build())
+// deprecation adds the deprecation note paragraph of the deprecated message or enum
+func deprecation(deprecated opt.Opt[string], section *md.Section) {
+	deprecated.IfPresent(func(reason string) {
+		paragraph := MkParagraph()
+		if reason == "" {
+			TextToParagraph(paragraph, "Deprecated", md.TextEmphasisBold)
+		} else {
+			TextToParagraph(paragraph, "Deprecated:", md.TextEmphasisBold)
+			TextToParagraph(paragraph, reason, md.TextEmphasisNormal)
+		}
+		section.AddElement(paragraph)
+	})
+}
+
+func deprecationNote(reason string) string {
+	if reason == "" {
+		return "Deprecated."
+	}
+
+	return "Deprecated: " + reason
+}
+
+// nameEmphasis returns the emphasis of field and enum value names in tables, deprecated ones are struck through
+func nameEmphasis(deprecated opt.Opt[string]) md.TextEmphasis {
+	if deprecated.Present() {
+		return md.TextEmphasisStrikethrough
+	}
+
+	return md.TextEmphasisBold
+}
+
+func (g *MDGenerator) code(code string, syntax Syntax, section *md.Section) {
+	section.AddElement(md.NewCodeblockBuilder().Text(code).Language(syntax.Language()).Build())
 }
 
 func pbTypeToString(d *protokit.FieldDescriptor) string {
@@ -287,7 +503,7 @@ func pbTypeToString(d *protokit.FieldDescriptor) string {
 	case descriptorpb.FieldDescriptorProto_TYPE_INT32:
 		return "int32"
 	case descriptorpb.FieldDescriptorProto_TYPE_UINT64:
-		return "uint62"
+		return "uint64"
 	case descriptorpb.FieldDescriptorProto_TYPE_UINT32:
 		return "uint32"
 	case descriptorpb.FieldDescriptorProto_TYPE_SINT64:
@@ -325,6 +541,15 @@ func pbTypeToString(d *protokit.FieldDescriptor) string {
 	}
 }
 
+// protoTypeName returns the field type as it's declared in .proto files, e.g. 'sint64', message and enum types are full names
+func protoTypeName(d *protokit.FieldDescriptor) string {
+	if typeName := trimTypeName(d.GetTypeName()); typeName != "" {
+		return typeName
+	}
+
+	return strings.ToLower(strings.TrimPrefix(d.GetType().String(), "TYPE_"))
+}
+
 func pbLabel(d *protokit.FieldDescriptor) string {
 	label := d.GetLabel()
 	if label == descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL {
@@ -334,7 +559,7 @@ func pbLabel(d *protokit.FieldDescriptor) string {
 	return label.String()
 }
 
-func listRecursive(entries []Entry, ordered bool, parent *md.List, level, levels int) *md.List {
+func listRecursive(entries []Entry, ordered bool, parent *md.List, level, levels int, badges bool) *md.List {
 	list := MkList(ordered, parent)
 
 	for _, entry := range entries {
@@ -342,16 +567,81 @@ func listRecursive(entries []Entry, ordered bool, parent *md.List, level, levels
 
 		switch entry.t {
 		case EntryTypeMessage:
-			listEntry.SetElement(MkLink(entry.msg.m.GetName(), entry.msg.m.GetFullName()))
+			listEntry.SetElement(entryLink(MkLink(entry.msg.m.GetName(), entry.msg.m.GetFullName()), entry.msg.since, badges))
 			if level < levels {
 				if len(entry.msg.entries) > 0 {
 					subList := MkList(ordered, list)
-					result := listRecursive(entry.msg.entries, ordered, subList, level+1, levels)
+					result := listRecursive(entry.msg.entries, ordered, subList, level+1, levels, badges)
 					listEntry.AddSublist(result)
 				}
 			}
 		case EntryTypeEnum:
-			listEntry.SetElement(MkLink(entry.enum.e.GetName(), entry.enum.e.GetFullName()))
+			listEntry.SetElement(entryLink(MkLink(entry.enum.e.GetName(), entry.enum.e.GetFullName()), entry.enum.since, badges))
+		}
+		list.AddEntry(listEntry)
+	}
+
+	return list
+}
+
+func entryLink(link *md.Link, since string, badges bool) md.Element {
+	if !badges {
+		return link
+	}
+
+	return sinceBadge(link, since)
+}
+
+// prefixSection returns the section of the prefix document source, which is written as is
+func prefixSection(source string) *md.Section {
+	section := md.NewSectionBuilder().Build()
+	section.AddElement(md.NewRawBuilder().Text(source).Build())
+
+	return section
+}
+
+type headingNode struct {
+	header   *md.Header
+	slug     string
+	children []*headingNode
+}
+
+/*
+prefixHeadings collects top level headers of the prefix document into a tree, deeper headers are nested under the preceding shallower ones.
+*/
+func prefixHeadings(prefix *md.Document) []*headingNode {
+	var roots []*headingNode
+	var stack []*headingNode
+	slugs := make(map[string]int)
+	for _, section := range prefix.GetSections() {
+		for _, element := range section.GetElements() {
+			header, ok := element.(*md.Header)
+			if !ok || header.GetText() == "" {
+				continue
+			}
+
+			node := &headingNode{header: header, slug: uniqueSlug(slugs, header.GetText())}
+			for len(stack) > 0 && stack[len(stack)-1].header.GetLevel() >= header.GetLevel() {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				roots = append(roots, node)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		}
+	}
+
+	return roots
+}
+
+func headingListRecursive(nodes []*headingNode, list *md.List) *md.List {
+	for _, node := range nodes {
+		listEntry := MkListEntry(list, md.NewLinkBuilder().Text(node.header.GetText()).Url("#"+node.slug).Build())
+		if len(node.children) > 0 {
+			listEntry.AddSublist(headingListRecursive(node.children, MkList(false, list)))
 		}
 		list.AddEntry(listEntry)
 	}
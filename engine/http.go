@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// HttpRuleExtension is the field number of the google.api.http method option (google/api/annotations.proto).
+const HttpRuleExtension protowire.Number = 72295728
+
+var httpPathParamPattern = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?}`)
+
+/*
+HttpRule is a google.api.http binding of an RPC method.
+The option is decoded from the raw method options, so google/api/annotations.proto doesn't have to be registered.
+*/
+type HttpRule struct {
+	method             string
+	path               string
+	body               string
+	additionalBindings []HttpRule
+}
+
+func NewHttpRule(method, path, body string) *HttpRule {
+	return &HttpRule{method: method, path: path, body: body}
+}
+
+func (r HttpRule) Method() string {
+	return r.method
+}
+
+func (r HttpRule) Path() string {
+	return r.path
+}
+
+func (r HttpRule) Body() string {
+	return r.body
+}
+
+func (r HttpRule) AdditionalBindings() []HttpRule {
+	return r.additionalBindings
+}
+
+/*
+PathParams returns names of the variables bound in the path template, e.g. 'name' for '/v1/{name=shelves/*}'.
+*/
+func (r HttpRule) PathParams() []string {
+	var result []string
+	for _, m := range httpPathParamPattern.FindAllStringSubmatch(r.path, -1) {
+		result = append(result, m[1])
+	}
+
+	return result
+}
+
+/*
+TemplatePath returns the path with all variable patterns stripped, e.g. '/v1/{name}' for '/v1/{name=shelves/*}'.
+*/
+func (r HttpRule) TemplatePath() string {
+	return httpPathParamPattern.ReplaceAllString(r.path, "{$1}")
+}
+
+func parseHttpRule(options *descriptorpb.MethodOptions) (*HttpRule, error) {
+	if options == nil {
+		return nil, nil
+	}
+
+	unknown := options.ProtoReflect().GetUnknown()
+	for len(unknown) > 0 {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		unknown = unknown[n:]
+		if num == HttpRuleExtension && typ == protowire.BytesType {
+			payload, n := protowire.ConsumeBytes(unknown)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+
+			return decodeHttpRule(payload)
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, unknown)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		unknown = unknown[n:]
+	}
+
+	return nil, nil
+}
+
+func decodeHttpRule(b []byte) (*HttpRule, error) {
+	result := &HttpRule{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+
+		value, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch num {
+		case 2:
+			result.method, result.path = http.MethodGet, string(value)
+		case 3:
+			result.method, result.path = http.MethodPut, string(value)
+		case 4:
+			result.method, result.path = http.MethodPost, string(value)
+		case 5:
+			result.method, result.path = http.MethodDelete, string(value)
+		case 6:
+			result.method, result.path = http.MethodPatch, string(value)
+		case 7:
+			result.body = string(value)
+		case 8:
+			kind, path, err := decodeCustomHttpPattern(value)
+			if err != nil {
+				return nil, err
+			}
+			result.method, result.path = kind, path
+		case 11:
+			binding, err := decodeHttpRule(value)
+			if err != nil {
+				return nil, err
+			}
+			result.additionalBindings = append(result.additionalBindings, *binding)
+		}
+	}
+
+	if result.method == "" {
+		return nil, fmt.Errorf("http rule has no method pattern")
+	}
+
+	return result, nil
+}
+
+func decodeCustomHttpPattern(b []byte) (string, string, error) {
+	var kind, path string
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return "", "", protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		value, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch num {
+		case 1:
+			kind = string(value)
+		case 2:
+			path = string(value)
+		}
+	}
+
+	return kind, path, nil
+}
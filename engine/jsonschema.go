@@ -0,0 +1,250 @@
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pseudomuto/protokit"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const SchemaRefPrefix = "#/components/schemas/"
+
+/*
+JSONSchema is the subset of JSON Schema shared by OpenAPI 3.0 and AsyncAPI 2.x documents.
+*/
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                 `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty" yaml:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Enum                 []string               `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Example              any                    `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+func SchemaRef(fullName string) *JSONSchema {
+	return &JSONSchema{Ref: SchemaRefPrefix + fullName}
+}
+
+type schemaBuilder struct {
+	files    []ParsedFile
+	codegen  *Codegenerator
+	messages map[string]*Message
+	enums    map[string]*Enum
+}
+
+func newSchemaBuilder(files []ParsedFile, codegen *Codegenerator) *schemaBuilder {
+	b := &schemaBuilder{
+		files:    files,
+		codegen:  codegen,
+		messages: make(map[string]*Message),
+		enums:    make(map[string]*Enum),
+	}
+	for _, file := range files {
+		b.index(file.entries)
+	}
+
+	return b
+}
+
+func (b *schemaBuilder) index(entries []Entry) {
+	for _, entry := range entries {
+		switch entry.t {
+		case EntryTypeMessage:
+			if entry.msg.m == nil {
+				continue
+			}
+			b.messages[entry.msg.m.GetFullName()] = entry.msg
+			b.index(entry.msg.entries)
+		case EntryTypeEnum:
+			if entry.enum.e == nil {
+				continue
+			}
+			b.enums[entry.enum.e.GetFullName()] = entry.enum
+		}
+	}
+}
+
+/*
+schemas returns component schemas of all the non map-entry messages and enums, keyed by the full proto name.
+*/
+func (b *schemaBuilder) schemas() map[string]*JSONSchema {
+	result := make(map[string]*JSONSchema)
+	for name, msg := range b.messages {
+		if isMapEntry(msg.m) {
+			continue
+		}
+		result[name] = b.messageSchema(msg)
+	}
+	for name, enum := range b.enums {
+		result[name] = b.enumSchema(enum)
+	}
+
+	return result
+}
+
+func (b *schemaBuilder) messageSchema(msg *Message) *JSONSchema {
+	result := &JSONSchema{
+		Type:        "object",
+		Description: msg.description,
+		Properties:  make(map[string]*JSONSchema),
+	}
+	for i := range msg.fields {
+		field := &msg.fields[i]
+		result.Properties[field.d.GetName()] = b.fieldSchema(field)
+	}
+	if example, ok := b.example(msg); ok {
+		result.Example = example
+	}
+
+	return result
+}
+
+func (b *schemaBuilder) enumSchema(enum *Enum) *JSONSchema {
+	result := &JSONSchema{
+		Type:        "string",
+		Description: enum.description,
+	}
+	for _, value := range enum.values {
+		result.Enum = append(result.Enum, value.d.GetName())
+	}
+
+	return result
+}
+
+func (b *schemaBuilder) fieldSchema(field *MessageField) *JSONSchema {
+	if entry, ok := b.messages[trimTypeName(field.d.GetTypeName())]; ok && isMapEntry(entry.m) {
+		result := &JSONSchema{Type: "object", Description: field.description}
+		for i := range entry.fields {
+			if entry.fields[i].d.GetNumber() == 2 {
+				result.AdditionalProperties = b.fieldSchema(&entry.fields[i])
+			}
+		}
+		if result.AdditionalProperties == nil {
+			result.AdditionalProperties = &JSONSchema{}
+		}
+		return result
+	}
+
+	item := b.scalarSchema(field)
+	flags := field.flags.OrElse(FieldFlags{})
+	flags.min.IfPresent(func(v float64) {
+		item.Minimum = &v
+	})
+	flags.max.IfPresent(func(v float64) {
+		item.Maximum = &v
+	})
+	if item.Ref == "" {
+		item.Pattern = flags.pattern.OrElse("")
+	}
+
+	if field.d.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		result := &JSONSchema{Type: "array", Description: field.description, Items: item}
+		flags.maxLength.IfPresent(func(v int) {
+			result.MaxItems = &v
+		})
+		return result
+	}
+
+	if item.Ref != "" {
+		// OpenAPI 3.0 ignores siblings of $ref, so the description is dropped for referenced types
+		return item
+	}
+	item.Description = field.description
+	flags.maxLength.IfPresent(func(v int) {
+		item.MaxLength = &v
+	})
+
+	return item
+}
+
+func (b *schemaBuilder) scalarSchema(field *MessageField) *JSONSchema {
+	switch field.d.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_INT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+		return &JSONSchema{Type: "integer", Format: "int32"}
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
+		return &JSONSchema{Type: "integer", Format: "int64"}
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		return &JSONSchema{Type: "number", Format: "float"}
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return &JSONSchema{Type: "number", Format: "double"}
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return &JSONSchema{Type: "boolean"}
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		return &JSONSchema{Type: "string", Format: "byte"}
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM,
+		descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+		descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		return SchemaRef(trimTypeName(field.d.GetTypeName()))
+	}
+
+	result := &JSONSchema{Type: "string"}
+	switch field.flags.OrElse(FieldFlags{}).GetCustomType().OrElse(field.ValueType()) {
+	case ValueTypeEmail:
+		result.Format = "email"
+	case ValueTypeUUID:
+		result.Format = "uuid"
+	case ValueTypePassword:
+		result.Format = "password"
+	}
+
+	return result
+}
+
+/*
+example returns the message @code payload, or generates one if @autocode is set.
+*/
+func (b *schemaBuilder) example(msg *Message) (any, bool) {
+	if code := msg.code.Get(); code != nil {
+		if code.Left != SyntaxJson {
+			return code.Right, true
+		}
+		var result any
+		if err := json.Unmarshal([]byte(code.Right), &result); err != nil {
+			log.Warn().Err(err).Msgf("failed to read '%s' code example", msg.m.GetFullName())
+			return nil, false
+		}
+		return result, true
+	}
+
+	if msg.autocode.Present() && b.codegen != nil {
+		generated, err := b.codegen.Generate(b.files, msg)
+		if err != nil {
+			log.Warn().Err(err).Msgf("failed to generate '%s' code example", msg.m.GetFullName())
+			return nil, false
+		}
+		var result any
+		if err := json.Unmarshal([]byte(generated.GetText()), &result); err != nil {
+			log.Warn().Err(err).Msgf("failed to read '%s' generated code example", msg.m.GetFullName())
+			return nil, false
+		}
+		return result, true
+	}
+
+	return nil, false
+}
+
+func isMapEntry(d *protokit.Descriptor) bool {
+	return d != nil && d.GetOptions().GetMapEntry()
+}
+
+func trimTypeName(typeName string) string {
+	return strings.TrimPrefix(typeName, ".")
+}
@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	"github.com/rs/zerolog/log"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+)
+
+// RegionScalars is the region name of the scalar value types section
+const RegionScalars = "scalars"
+
+// scalar value types in the order of the reference table, names are the .proto ones returned by protoTypeName
+var scalarTypes = []scalarType{
+	{"double", "64-bit floating point number"},
+	{"float", "32-bit floating point number"},
+	{"int32", "32-bit signed integer, inefficient for negative numbers"},
+	{"int64", "64-bit signed integer, inefficient for negative numbers"},
+	{"uint32", "32-bit unsigned integer"},
+	{"uint64", "64-bit unsigned integer"},
+	{"sint32", "32-bit signed integer, efficient for negative numbers"},
+	{"sint64", "64-bit signed integer, efficient for negative numbers"},
+	{"fixed32", "unsigned 32-bit, always 4 bytes"},
+	{"fixed64", "unsigned 64-bit, always 8 bytes"},
+	{"sfixed32", "signed 32-bit, always 4 bytes"},
+	{"sfixed64", "signed 64-bit, always 8 bytes"},
+	{"bool", "true or false"},
+	{"string", "UTF-8 encoded text"},
+	{"bytes", "arbitrary sequence of bytes, base64 encoded in JSON"},
+}
+
+type scalarType struct {
+	name        string
+	description string
+}
+
+type typeTarget struct {
+	page   string
+	anchor string
+}
+
+/*
+linkResolver points type reference links, which are '#' + type full name, to the type anchors. Types documented on another
+page are linked as 'page.md#anchor', scalars are linked to the scalar value types table.
+*/
+type linkResolver struct {
+	types       map[string]typeTarget
+	scalarsPage string
+}
+
+func newLinkResolver(parsedFiles []ParsedFile, pageOf func(file ParsedFile) string) linkResolver {
+	types := make(map[string]typeTarget)
+	var index func(page string, entries []Entry)
+	index = func(page string, entries []Entry) {
+		for _, entry := range entries {
+			switch {
+			case entry.t == EntryTypeMessage && entry.msg.m != nil:
+				types[entry.msg.m.GetFullName()] = typeTarget{page: page, anchor: typeAnchor(entry.msg.m.GetFullName())}
+				index(page, entry.msg.entries)
+			case entry.t == EntryTypeEnum && entry.enum.e != nil:
+				types[entry.enum.e.GetFullName()] = typeTarget{page: page, anchor: typeAnchor(entry.enum.e.GetFullName())}
+			}
+		}
+	}
+	for _, file := range parsedFiles {
+		index(pageOf(file), file.entries)
+	}
+
+	return linkResolver{types: types}
+}
+
+/*
+resolve rewrites '#' links of the page document to the resolved anchors and returns the scalar types it links to.
+Links, which point neither to a type nor to an anchor of the page, are reported as warnings and left as is.
+*/
+func (l linkResolver) resolve(document *md.Document, page string) []string {
+	anchors := pageAnchors(document)
+	var scalars []string
+	md.Walk(document, func(element md.Element) {
+		link, ok := element.(*md.Link)
+		if !ok || !strings.HasPrefix(link.GetUrl(), "#") {
+			return
+		}
+
+		reference := link.GetUrl()[1:]
+		if url, scalar, ok := l.typeUrl(reference, page); ok {
+			link.SetUrl(url)
+			if scalar && arrayutils.Contains(reference, scalars) == -1 {
+				scalars = append(scalars, reference)
+			}
+			return
+		}
+		if !anchors[reference] {
+			log.Warn().Msgf("unresolved link '%s' to '%s'%s", link.GetText(), link.GetUrl(), pageSuffix(page))
+		}
+	})
+
+	return scalars
+}
+
+// typeUrl returns the url of the message, enum or scalar type on the page, ok is false if the reference is neither of them
+func (l linkResolver) typeUrl(reference, page string) (url string, scalar bool, ok bool) {
+	if target, ok := l.types[reference]; ok {
+		return pageUrl(target.page, page) + "#" + target.anchor, false, true
+	}
+	if isScalarType(reference) {
+		return pageUrl(l.scalarsPage, page) + "#" + scalarAnchor(reference), true, true
+	}
+
+	return "", false, false
+}
+
+// scalarSection returns the reference table of the scalar types
+func scalarSection(scalars []string, heading string) *md.Section {
+	section := md.NewSectionBuilder().Name(RegionScalars).Build()
+	section.AddElement(MkHeader(heading, md.HeaderLevelTwo))
+
+	colType := md.NewColumnBuilder().Name("Type").Build()
+	colDesc := md.NewColumnBuilder().Name("Description").Build()
+	rows := 0
+	for _, scalar := range scalarTypes {
+		if arrayutils.Contains(scalar.name, scalars) == -1 {
+			continue
+		}
+		// table cells can't hold anchors, so they are placed before the table
+		section.AddElement(md.NewHtmlRefBuilder().Name(scalarAnchor(scalar.name)).Build())
+		typeRow := MkRow()
+		typeRow.AddText(MkText(scalar.name, md.TextEmphasisCode))
+		colType.AddRow(typeRow)
+
+		descRow := MkRow()
+		descRow.AddText(MkText(scalar.description, md.TextEmphasisNormal))
+		colDesc.AddRow(descRow)
+		rows++
+	}
+
+	table := md.NewTableBuilder().Rows(rows).Build()
+	table.AddColumn(colType)
+	table.AddColumn(colDesc)
+	section.AddElement(table)
+
+	return section
+}
+
+// typeAnchor returns anchor name of the message or enum, dots are replaced since they are not preserved by GitHub
+func typeAnchor(fullName string) string {
+	return md.Slug(strings.ReplaceAll(fullName, ".", "-"))
+}
+
+func scalarAnchor(name string) string {
+	return "scalar-" + md.Slug(name)
+}
+
+func isScalarType(name string) bool {
+	for _, scalar := range scalarTypes {
+		if scalar.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pageAnchors collects html anchors and heading anchors of the document, including the ones of the raw markdown
+func pageAnchors(document *md.Document) map[string]bool {
+	anchors := make(map[string]bool)
+	slugs := make(map[string]int)
+	var collect func(element md.Element)
+	collect = func(element md.Element) {
+		switch e := element.(type) {
+		case *md.HtmlRef:
+			anchors[e.GetName()] = true
+		case *md.Header:
+			anchors[uniqueSlug(slugs, e.GetText())] = true
+		case *md.Raw:
+			if parsed, err := md.Parse(e.GetText()); err == nil {
+				md.Walk(parsed, collect)
+			}
+		}
+	}
+	md.Walk(document, collect)
+
+	return anchors
+}
+
+/*
+uniqueSlug returns heading anchor, repeated ones are suffixed with '-1', '-2' etc. the same way GitHub does it, suffixes skip
+the anchors taken by other headings, e.g. 'Intro', 'Intro-1', 'Intro' get 'intro', 'intro-1' and 'intro-2'.
+It's shared by TOC links and link validation, so both of them get the same anchors.
+*/
+func uniqueSlug(slugs map[string]int, text string) string {
+	slug := md.Slug(text)
+	result := slug
+	for {
+		if _, ok := slugs[result]; !ok {
+			break
+		}
+		slugs[slug]++
+		result = fmt.Sprintf("%s-%d", slug, slugs[slug])
+	}
+	slugs[result] = 0
+
+	return result
+}
+
+func pageUrl(target, current string) string {
+	if target == current {
+		return ""
+	}
+
+	return target
+}
+
+func pageSuffix(page string) string {
+	if page == "" {
+		return ""
+	}
+
+	return " on page '" + page + "'"
+}
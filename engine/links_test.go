@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestLinkResolver_resolve(t *testing.T) {
+	var logs bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&logs)
+	defer func() {
+		log.Logger = logger
+	}()
+
+	link := func(url string) *md.Link {
+		return md.NewLinkBuilder().Text(url[1:]).Url(url).Build()
+	}
+	message, local, scalar, heading, anchor, missing, external :=
+		link("#pkg.Message"), link("#pkg.Local"), link("#string"), link("#getting-started"), link("#custom"), link("#pkg.Missing"), link("#x")
+	external.SetUrl("https://example.com/#x")
+
+	section := md.NewSectionBuilder().Build()
+	section.AddElement(MkHeader("Getting started", md.HeaderLevelOne))
+	section.AddElement(md.NewHtmlRefBuilder().Name("custom").Build())
+	paragraph := MkParagraph()
+	paragraph.AddElement(message)
+	paragraph.AddElement(local)
+	section.AddElement(paragraph)
+	list := MkList(false, nil)
+	list.AddEntry(MkListEntry(list, heading))
+	list.AddEntry(MkListEntry(list, anchor))
+	section.AddElement(list)
+	table := MkTable(1)
+	table.AddColumn(md.NewColumnBuilder().Rows(*md.NewRowBuilder().Elements(scalar, missing, external).Build()).Build())
+	section.AddElement(table)
+	document := md.NewDocumentBuilder().Build()
+	document.AddSection(section)
+
+	links := linkResolver{
+		types: map[string]typeTarget{
+			"pkg.Message": {page: "other.md", anchor: "pkg-message"},
+			"pkg.Local":   {page: "page.md", anchor: "pkg-local"},
+		},
+		scalarsPage: IndexPageName,
+	}
+	scalars := links.resolve(document, "page.md")
+
+	assert.Equal(t, []string{"string"}, scalars)
+	assert.Equal(t, "other.md#pkg-message", message.GetUrl())
+	assert.Equal(t, "#pkg-local", local.GetUrl())
+	assert.Equal(t, "index.md#scalar-string", scalar.GetUrl())
+	assert.Equal(t, "#getting-started", heading.GetUrl())
+	assert.Equal(t, "#custom", anchor.GetUrl())
+	assert.Equal(t, "#pkg.Missing", missing.GetUrl())
+	assert.Equal(t, "https://example.com/#x", external.GetUrl())
+	assert.Contains(t, logs.String(), "unresolved link 'pkg.Missing' to '#pkg.Missing' on page 'page.md'")
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("unresolved link")))
+}
+
+func TestTypeAnchor(t *testing.T) {
+	assert.Equal(t, "doc_generator_test-clientrequest", typeAnchor("doc_generator_test.ClientRequest"))
+	assert.Equal(t, "api-v1-outer-inner", typeAnchor("api.v1.Outer.Inner"))
+	assert.Equal(t, "scalar-sfixed64", scalarAnchor("sfixed64"))
+}
+
+func TestNewLinkResolver(t *testing.T) {
+	files := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		orders.MessageType[0].NestedType = []*descriptorpb.DescriptorProto{{Name: proto.String("Line")}}
+	})
+	links := newLinkResolver(files, func(file ParsedFile) string { return file.filename })
+
+	assert.Equal(t, typeTarget{page: "api/orders.proto", anchor: "shop-order-line"}, links.types["shop.Order.Line"])
+	assert.Equal(t, typeTarget{page: "api/common.proto", anchor: "common-money"}, links.types["common.Money"])
+}
+
+func TestScalarLinks(t *testing.T) {
+	files := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		common.MessageType[0].Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_FIXED64.Enum()
+	})
+	document, err := NewMDGenerator(NewSeededCodegenerator(1)).Generate(files)
+	require.NoError(t, err)
+	content, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), document)
+	require.NoError(t, err)
+
+	assert.Contains(t, content, "(#scalar-fixed64)")
+	assert.Contains(t, content, `<a name="scalar-fixed64"></a>`)
+	assert.Contains(t, content, "unsigned 64-bit, always 8 bytes")
+	assert.NotContains(t, content, "scalar-float64")
+}
@@ -1,10 +1,5 @@
 package md
 
-import (
-	"fmt"
-	"strings"
-)
-
 // ColumnBuilder Column builder pattern code
 type ColumnBuilder struct {
 	column *Column
@@ -171,6 +166,16 @@ func (b *CodeblockBuilder) Text(text string) *CodeblockBuilder {
 	return b
 }
 
+func (b *CodeblockBuilder) Language(language string) *CodeblockBuilder {
+	b.codeblock.language = language
+	return b
+}
+
+func (b *CodeblockBuilder) Title(title string) *CodeblockBuilder {
+	b.codeblock.title = title
+	return b
+}
+
 func (b *CodeblockBuilder) Build() *Codeblock {
 	return b.codeblock
 }
@@ -317,10 +322,6 @@ func (b *TextBuilder) Emphasis(emphasis TextEmphasis) *TextBuilder {
 }
 
 func (b *TextBuilder) Build() *Text {
-	if strings.ContainsAny(b.text.GetText(), "#^&*") {
-		panic(fmt.Errorf("text cannot contain special characters: %s, string: '%s'", "#^&*", b.text.GetText()))
-	}
-
 	return b.text
 }
 
@@ -395,6 +396,11 @@ func (b *SectionBuilder) Index(index int) *SectionBuilder {
 	return b
 }
 
+func (b *SectionBuilder) Name(name string) *SectionBuilder {
+	b.section.name = name
+	return b
+}
+
 func (b *SectionBuilder) Elements(elements ...Element) *SectionBuilder {
 	b.section.elements = elements
 	return b
@@ -443,3 +449,23 @@ func (b *HtmlRefBuilder) Name(name string) *HtmlRefBuilder {
 func (b *HtmlRefBuilder) Build() *HtmlRef {
 	return b.htmlRef
 }
+
+// RawBuilder Raw builder pattern code
+type RawBuilder struct {
+	raw *Raw
+}
+
+func NewRawBuilder() *RawBuilder {
+	raw := &Raw{}
+	b := &RawBuilder{raw: raw}
+	return b
+}
+
+func (b *RawBuilder) Text(text string) *RawBuilder {
+	b.raw.text = text
+	return b
+}
+
+func (b *RawBuilder) Build() *Raw {
+	return b.raw
+}
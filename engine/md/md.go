@@ -30,6 +30,7 @@ const (
 	ElementTypeTable
 	ElementTypeRow
 	ElementTypeHtmlRef
+	ElementTypeRaw
 )
 
 const (
@@ -73,11 +74,14 @@ const (
 	TextEmphasisBold
 	TextEmphasisItalic
 	TextEmphasisBoldItalic
+	TextEmphasisCode
+	TextEmphasisStrikethrough
 )
 
-//goland:noinspection GoUnusedConst
+// ColumnAlignmentDefault leaves alignment to the renderer of the document, i.e. plain '---' separator is used.
 const (
-	ColumnAlignmentLeft ColumnAlignment = iota
+	ColumnAlignmentDefault ColumnAlignment = iota
+	ColumnAlignmentLeft
 	ColumnAlignmentCenter
 	ColumnAlignmentRight
 )
@@ -85,8 +89,10 @@ const (
 const EmphasisBoldAsterisksDelimiter = "**"
 const EmphasisBoldUnderscoresDelimiter = "__"
 const EmphasisItalicAsteriskDelimiter = "*"
+const EmphasisItalicUnderscoreDelimiter = "_"
 const EmphasisBoldItalicAsteriskDelimiter = "***"
 const EmphasisBoldItalicUnderscoresDelimiter = "___"
+const EmphasisStrikethroughDelimiter = "~~"
 
 const CodeblockBackticksDelimiter = "```"
 const CodeblockTildasDelimiter = "~~~"
@@ -103,14 +109,9 @@ const ListAsteriskDelimiter = "*"
 const ListDashDelimiter = "-"
 const ListPlusDelimiter = "+"
 
-// ColumnAlignmentLeftDelimiter TODO: Implement alignment
-//goland:noinspection GoUnusedConst
+const ColumnAlignmentDefaultDelimiter = "---"
 const ColumnAlignmentLeftDelimiter = ":---"
-
-//goland:noinspection GoUnusedConst
 const ColumnAlignmentCenterDelimiter = ":----:"
-
-//goland:noinspection GoUnusedConst
 const ColumnAlignmentRightDelimiter = "---:"
 
 type Element interface {
@@ -154,9 +155,21 @@ func (d *Document) AddSection(section *Section) {
 
 type Section struct {
 	OrderedSafeElement
+	name     string // optional name, used to address the section, e.g. as a splice region
 	elements []Element
 }
 
+func (s *Section) GetName() string {
+	return s.name
+}
+
+func (s *Section) SetName(name string) {
+	if s.blocked {
+		panic(fmt.Errorf("operation on blocked element: %+v", s))
+	}
+	s.name = name
+}
+
 func (s *Section) GetElements() []Element {
 	return s.elements
 }
@@ -367,7 +380,9 @@ func (l *List) AddEntry(entry *ListEntry) {
 
 type Codeblock struct {
 	OrderedSafeElement
-	text string
+	text     string
+	language string
+	title    string
 }
 
 func (c *Codeblock) GetText() string {
@@ -381,6 +396,30 @@ func (c *Codeblock) AddText(text string) {
 	c.text = text
 }
 
+// GetLanguage returns language of the info string used for syntax highlighting, e.g. 'json'.
+func (c *Codeblock) GetLanguage() string {
+	return c.language
+}
+
+func (c *Codeblock) SetLanguage(language string) {
+	if c.blocked {
+		panic(fmt.Errorf("operation on blocked element: %+v", c))
+	}
+	c.language = language
+}
+
+// GetTitle returns optional caption rendered above the code block.
+func (c *Codeblock) GetTitle() string {
+	return c.title
+}
+
+func (c *Codeblock) SetTitle(title string) {
+	if c.blocked {
+		panic(fmt.Errorf("operation on blocked element: %+v", c))
+	}
+	c.title = title
+}
+
 type Image struct {
 	OrderedSafeElement
 	url, text, title string
@@ -535,6 +574,16 @@ func (r *HtmlRef) SetName(name string) {
 	r.name = name
 }
 
+// Raw is the markdown source, which is written as is, e.g. the prefix document
+type Raw struct {
+	OrderedSafeElement
+	text string
+}
+
+func (r *Raw) GetText() string {
+	return r.text
+}
+
 //goland:noinspection GoMixedReceiverTypes
 func (h Header) GetType() ElementType {
 	return ElementTypeHeader
@@ -587,3 +636,7 @@ func (r Row) GetType() ElementType {
 func (r HtmlRef) GetType() ElementType {
 	return ElementTypeHtmlRef
 }
+
+func (r Raw) GetType() ElementType {
+	return ElementTypeRaw
+}
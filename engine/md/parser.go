@@ -0,0 +1,645 @@
+package md
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var atxHeadingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+var setextUnderlinePattern = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+var fencePattern = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+var listItemPattern = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])(?:[ \t]+(.*))?$`)
+var tableDelimiterPattern = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+var htmlRefPattern = regexp.MustCompile(`^[ \t]*<a name="([^"]*)"></a>[ \t]*$`)
+var lineBreakPattern = regexp.MustCompile(`(?i)^<br[ \t]*/?>`)
+
+/*
+Parse reads a CommonMark/GFM document into a single section document.
+Supported blocks are ATX and setext headings, paragraphs, fenced code blocks, block quotes, lists, thematic breaks,
+GFM tables and '<a name="..."></a>' anchors. Inline emphasis, code spans, links and images are read into paragraph elements,
+nested emphasis is flattened into the outer one.
+*/
+func Parse(source string) (*Document, error) {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	parser := &blockParser{lines: strings.Split(source, "\n")}
+	elements, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	section := NewSectionBuilder().Build()
+	for _, element := range elements {
+		section.AddElement(element)
+	}
+	document := NewDocumentBuilder().Build()
+	document.AddSection(section)
+
+	return document, nil
+}
+
+/*
+Slug returns GitHub compatible anchor of the heading text, duplicates are expected to be suffixed with '-1', '-2' etc.
+*/
+func Slug(text string) string {
+	var builder strings.Builder
+	for _, ch := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '-' || ch == '_':
+			builder.WriteRune(ch)
+		case ch == ' ':
+			builder.WriteRune('-')
+		}
+	}
+
+	return builder.String()
+}
+
+type blockParser struct {
+	lines []string
+	pos   int
+	// offset is the line number of the first line, used in error messages of nested parsers
+	offset int
+}
+
+func (p *blockParser) parse() ([]Element, error) {
+	var result []Element
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "" {
+			p.pos++
+			continue
+		}
+
+		var element Element
+		var err error
+		switch {
+		case fencePattern.MatchString(line) && isFence(line):
+			element, err = p.codeblock()
+		case atxHeadingPattern.MatchString(line):
+			element = p.atxHeading()
+		case htmlRefPattern.MatchString(line):
+			element = NewHtmlRefBuilder().Name(html.UnescapeString(htmlRefPattern.FindStringSubmatch(line)[1])).Build()
+			p.pos++
+		case isThematicBreak(line):
+			element = NewRuleBuilder().Build()
+			p.pos++
+		case isBlockquote(line):
+			element, err = p.blockquote()
+		case listItemPattern.MatchString(line):
+			element = p.list(indentOf(listItemPattern.FindStringSubmatch(line)[1]), 0)
+		case p.pos+1 < len(p.lines) && strings.Contains(line, "|") && tableDelimiterPattern.MatchString(p.lines[p.pos+1]):
+			element = p.table()
+		default:
+			element = p.paragraph()
+		}
+		if err != nil {
+			return nil, err
+		}
+		element.SetIndex(len(result))
+		result = append(result, element)
+	}
+
+	return result, nil
+}
+
+func (p *blockParser) codeblock() (Element, error) {
+	start := p.pos
+	match := fencePattern.FindStringSubmatch(p.lines[p.pos])
+	indent, fence, info := len(match[1]), match[2], strings.TrimSpace(match[3])
+	language := ""
+	if fields := strings.Fields(info); len(fields) > 0 {
+		language = fields[0]
+	}
+
+	var content []string
+	for p.pos++; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) <= 3 && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]+" \t") == "" {
+			p.pos++
+			return NewCodeblockBuilder().Text(strings.Join(content, "\n")).Language(language).Build(), nil
+		}
+		for i := 0; i < indent && strings.HasPrefix(line, " "); i++ {
+			line = line[1:]
+		}
+		content = append(content, line)
+	}
+
+	return nil, fmt.Errorf("unclosed code block started at line %d", p.offset+start+1)
+}
+
+func (p *blockParser) atxHeading() Element {
+	match := atxHeadingPattern.FindStringSubmatch(p.lines[p.pos])
+	p.pos++
+
+	return NewHeaderBuilder().Level(HeaderLevel(len(match[1]))).Text(plainText(parseInline(match[2]))).Build()
+}
+
+func (p *blockParser) blockquote() (Element, error) {
+	start := p.pos
+	var content []string
+	for ; p.pos < len(p.lines) && isBlockquote(p.lines[p.pos]); p.pos++ {
+		line := strings.TrimLeft(p.lines[p.pos], " ")[1:]
+		content = append(content, strings.TrimPrefix(line, " "))
+	}
+
+	nested := &blockParser{lines: content, offset: p.offset + start}
+	elements, err := nested.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBlockquoteBuilder().Elements(indexed(elements)...).Build(), nil
+}
+
+/*
+list reads list items of the same indentation, more indented items are read as sublists of the previous item.
+*/
+func (p *blockParser) list(indent, level int) *List {
+	ordered := isOrderedMarker(listItemPattern.FindStringSubmatch(p.lines[p.pos])[2])
+	list := NewListBuilder().Ordered(ordered).Build()
+	list.SetLevel(level)
+
+	var entry *ListEntry
+	addEntry := func() {
+		if entry != nil {
+			list.AddEntry(entry)
+			entry = nil
+		}
+	}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "" {
+			// blank lines between items keep the list going
+			next := p.pos + 1
+			for next < len(p.lines) && strings.TrimSpace(p.lines[next]) == "" {
+				next++
+			}
+			if next < len(p.lines) && listItemPattern.MatchString(p.lines[next]) && indentOf(listItemPattern.FindStringSubmatch(p.lines[next])[1]) >= indent {
+				p.pos = next
+				continue
+			}
+			break
+		}
+
+		match := listItemPattern.FindStringSubmatch(line)
+		if match == nil {
+			if entry == nil || indentOf(line) <= indent {
+				break
+			}
+			// continuation line of the item text
+			if text, ok := entry.GetElement().(*Text); ok {
+				text.Add("\n" + strings.TrimSpace(line))
+			}
+			p.pos++
+			continue
+		}
+
+		itemIndent := indentOf(match[1])
+		if itemIndent < indent {
+			break
+		}
+		if itemIndent > indent {
+			if entry == nil {
+				entry = NewListEntryBuilder(list).Build()
+			}
+			entry.AddSublist(p.list(itemIndent, level+1))
+			continue
+		}
+		if isOrderedMarker(match[2]) != ordered {
+			break
+		}
+
+		addEntry()
+		entry = NewListEntryBuilder(list).Build()
+		if inline := parseInline(match[3]); len(inline) == 1 {
+			entry.SetElement(inline[0])
+		} else if len(inline) > 1 {
+			entry.SetElement(NewParagraphBuilder().Elements(indexed(inline)...).Build())
+		}
+		p.pos++
+	}
+	addEntry()
+
+	return list
+}
+
+func (p *blockParser) table() *Table {
+	header := splitTableRow(p.lines[p.pos])
+	delimiters := splitTableRow(p.lines[p.pos+1])
+	p.pos += 2
+
+	var body [][]string
+	for ; p.pos < len(p.lines) && strings.TrimSpace(p.lines[p.pos]) != "" && strings.Contains(p.lines[p.pos], "|"); p.pos++ {
+		body = append(body, splitTableRow(p.lines[p.pos]))
+	}
+
+	table := NewTableBuilder().Rows(len(body)).Build()
+	for c, name := range header {
+		alignment := ColumnAlignmentDefault
+		if c < len(delimiters) {
+			alignment = parseColumnAlignment(delimiters[c])
+		}
+		column := NewColumnBuilder().Index(c).Name(plainText(parseInline(name))).Alignment(alignment).Build()
+		for _, cells := range body {
+			var elements []Element
+			if c < len(cells) {
+				elements = indexed(parseInline(cells[c]))
+			}
+			column.AddRow(NewRowBuilder().Elements(elements...).Build())
+		}
+		table.AddColumn(column)
+	}
+
+	return table
+}
+
+/*
+paragraph reads lines up to a blank line or another block start, paragraph followed by setext underline becomes a heading.
+*/
+func (p *blockParser) paragraph() Element {
+	var content []string
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if len(content) > 0 {
+			if match := setextUnderlinePattern.FindStringSubmatch(line); match != nil {
+				p.pos++
+				level := HeaderLevelTwo
+				if strings.HasPrefix(match[1], "=") {
+					level = HeaderLevelOne
+				}
+				return NewHeaderBuilder().Level(level).Text(plainText(parseInline(strings.Join(content, " ")))).Build()
+			}
+			if interruptsParagraph(line) {
+				break
+			}
+		}
+		content = append(content, strings.TrimSpace(line))
+		p.pos++
+	}
+
+	return NewParagraphBuilder().Elements(indexed(parseInline(strings.Join(content, "\n")))...).Build()
+}
+
+func interruptsParagraph(line string) bool {
+	return strings.TrimSpace(line) == "" ||
+		(fencePattern.MatchString(line) && isFence(line)) ||
+		atxHeadingPattern.MatchString(line) ||
+		htmlRefPattern.MatchString(line) ||
+		isThematicBreak(line) ||
+		isBlockquote(line) ||
+		listItemPattern.MatchString(line)
+}
+
+func isFence(line string) bool {
+	match := fencePattern.FindStringSubmatch(line)
+	// backtick fence info string cannot contain backticks
+	return !strings.HasPrefix(match[2], "`") || !strings.Contains(match[3], "`")
+}
+
+func isBlockquote(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	return len(line)-len(trimmed) <= 3 && strings.HasPrefix(trimmed, ">")
+}
+
+func isThematicBreak(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || trimmed == "" {
+		return false
+	}
+	marker := trimmed[0]
+	if marker != '-' && marker != '*' && marker != '_' {
+		return false
+	}
+	count := 0
+	for _, ch := range trimmed {
+		switch {
+		case ch == rune(marker):
+			count++
+		case ch != ' ' && ch != '\t':
+			return false
+		}
+	}
+
+	return count >= 3
+}
+
+func isOrderedMarker(marker string) bool {
+	return marker != "-" && marker != "*" && marker != "+"
+}
+
+func indentOf(line string) int {
+	indent := 0
+	for _, ch := range line {
+		switch ch {
+		case ' ':
+			indent++
+		case '\t':
+			indent += 4
+		default:
+			return indent
+		}
+	}
+
+	return indent
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			// escaped pipes are part of the cell, even within code spans
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func parseColumnAlignment(delimiter string) ColumnAlignment {
+	left := strings.HasPrefix(delimiter, ":")
+	right := strings.HasSuffix(delimiter, ":")
+	switch {
+	case left && right:
+		return ColumnAlignmentCenter
+	case left:
+		return ColumnAlignmentLeft
+	case right:
+		return ColumnAlignmentRight
+	default:
+		return ColumnAlignmentDefault
+	}
+}
+
+/*
+parseInline reads text into text, link and image elements.
+Single space after emphasized text and code spans is skipped, since renderers separate them from the following text.
+*/
+func parseInline(source string) []Element {
+	runes := []rune(source)
+	var result []Element
+	var buf strings.Builder
+	flush := func() {
+		if buf.Len() > 0 {
+			result = append(result, NewTextBuilder().Text(buf.String()).Build())
+			buf.Reset()
+		}
+	}
+	add := func(element Element, next int) int {
+		flush()
+		result = append(result, element)
+		return next
+	}
+	skipSpace := func(i int) int {
+		if i < len(runes) && runes[i] == ' ' {
+			return i + 1
+		}
+		return i
+	}
+
+	for i := 0; i < len(runes); {
+		ch := runes[i]
+		switch {
+		case ch == '\\' && i+1 < len(runes) && isASCIIPunct(runes[i+1]):
+			buf.WriteRune(runes[i+1])
+			i += 2
+			continue
+		case ch == '<':
+			if match := lineBreakPattern.FindString(string(runes[i:])); match != "" {
+				buf.WriteRune('\n')
+				i += utf8.RuneCountInString(match)
+				continue
+			}
+		case ch == '`':
+			n := runLength(runes, i, '`')
+			if end := findCodeSpanEnd(runes, i+n, n); end != -1 {
+				code := string(runes[i+n : end])
+				if len(code) > 1 && strings.HasPrefix(code, " ") && strings.HasSuffix(code, " ") && strings.TrimSpace(code) != "" {
+					code = code[1 : len(code)-1]
+				}
+				i = skipSpace(add(NewTextBuilder().Text(code).Emphasis(TextEmphasisCode).Build(), end+n))
+				continue
+			}
+			buf.WriteString(string(runes[i : i+n]))
+			i += n
+			continue
+		case ch == '!' && i+1 < len(runes) && runes[i+1] == '[':
+			if text, url, title, end, ok := parseLinkAt(runes, i+1); ok {
+				i = add(NewImageBuilder().Text(text).Url(url).Title(title).Build(), end)
+				continue
+			}
+		case ch == '[':
+			if text, url, _, end, ok := parseLinkAt(runes, i); ok {
+				i = add(NewLinkBuilder().Text(text).Url(url).Build(), end)
+				continue
+			}
+		case ch == '~' && runLength(runes, i, ch) == 2:
+			if end := findEmphasisEnd(runes, i+2, ch, 2); end != -1 {
+				text := NewTextBuilder().Text(plainText(parseInline(string(runes[i+2 : end])))).Emphasis(TextEmphasisStrikethrough).Build()
+				i = skipSpace(add(text, end+2))
+				continue
+			}
+			buf.WriteString("~~")
+			i += 2
+			continue
+		case ch == '*' || (ch == '_' && (i == 0 || !isWordRune(runes[i-1]))):
+			n := runLength(runes, i, ch)
+			if n <= 3 {
+				if end := findEmphasisEnd(runes, i+n, ch, n); end != -1 {
+					text := NewTextBuilder().Text(plainText(parseInline(string(runes[i+n : end])))).Emphasis(emphasisOf(n)).Build()
+					i = skipSpace(add(text, end+n))
+					continue
+				}
+			}
+			buf.WriteString(string(runes[i : i+n]))
+			i += n
+			continue
+		}
+		buf.WriteRune(ch)
+		i++
+	}
+	flush()
+
+	return result
+}
+
+/*
+parseLinkAt reads '[text](url "title")' starting at the opening bracket and returns the index after the closing parenthesis.
+*/
+func parseLinkAt(runes []rune, start int) (text, url, title string, end int, ok bool) {
+	depth := 0
+	closing := -1
+	for i := start; i < len(runes) && closing == -1; i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = i
+			}
+		}
+	}
+	if closing == -1 || closing+1 >= len(runes) || runes[closing+1] != '(' {
+		return "", "", "", 0, false
+	}
+
+	i := closing + 2
+	var destination strings.Builder
+	if i < len(runes) && runes[i] == '<' {
+		for i++; i < len(runes) && runes[i] != '>'; i++ {
+			destination.WriteRune(runes[i])
+		}
+		i++
+	} else {
+		parens := 0
+		for ; i < len(runes) && runes[i] != ' ' && runes[i] != '\n'; i++ {
+			if runes[i] == '(' {
+				parens++
+			} else if runes[i] == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			}
+			destination.WriteRune(runes[i])
+		}
+	}
+	for i < len(runes) && (runes[i] == ' ' || runes[i] == '\n') {
+		i++
+	}
+	if i < len(runes) && (runes[i] == '"' || runes[i] == '\'') {
+		quote := runes[i]
+		var t strings.Builder
+		for i++; i < len(runes) && runes[i] != quote; i++ {
+			if runes[i] == '\\' && i+1 < len(runes) && isASCIIPunct(runes[i+1]) {
+				i++
+			}
+			t.WriteRune(runes[i])
+		}
+		title = t.String()
+		for i++; i < len(runes) && runes[i] == ' '; i++ {
+		}
+	}
+	if i >= len(runes) || runes[i] != ')' {
+		return "", "", "", 0, false
+	}
+
+	return plainText(parseInline(string(runes[start+1 : closing]))), destination.String(), title, i + 1, true
+}
+
+func findCodeSpanEnd(runes []rune, from, n int) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == '`' {
+			run := runLength(runes, i, '`')
+			if run == n {
+				return i
+			}
+			i += run - 1
+		}
+	}
+
+	return -1
+}
+
+func findEmphasisEnd(runes []rune, from int, delimiter rune, n int) int {
+	if from >= len(runes) || runes[from] == ' ' || runes[from] == '\n' {
+		return -1
+	}
+	for i := from; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\':
+			i++
+		case runes[i] == '`':
+			run := runLength(runes, i, '`')
+			if end := findCodeSpanEnd(runes, i+run, run); end != -1 {
+				i = end + run - 1
+			}
+		case runes[i] == delimiter:
+			run := runLength(runes, i, delimiter)
+			closes := run == n && i > from && runes[i-1] != ' ' && runes[i-1] != '\n'
+			if delimiter == '_' && i+run < len(runes) && isWordRune(runes[i+run]) {
+				closes = false
+			}
+			if closes {
+				return i
+			}
+			i += run - 1
+		}
+	}
+
+	return -1
+}
+
+func emphasisOf(delimiters int) TextEmphasis {
+	switch delimiters {
+	case 1:
+		return TextEmphasisItalic
+	case 2:
+		return TextEmphasisBold
+	default:
+		return TextEmphasisBoldItalic
+	}
+}
+
+func runLength(runes []rune, from int, ch rune) int {
+	n := 0
+	for from+n < len(runes) && runes[from+n] == ch {
+		n++
+	}
+
+	return n
+}
+
+// plainText concatenates text of inline elements, emphasis is dropped
+func plainText(elements []Element) string {
+	var builder strings.Builder
+	for _, element := range elements {
+		switch e := element.(type) {
+		case *Text:
+			builder.WriteString(e.GetText())
+		case *Link:
+			builder.WriteString(e.GetText())
+		case *Image:
+			builder.WriteString(e.GetText())
+		}
+	}
+
+	return builder.String()
+}
+
+func indexed(elements []Element) []Element {
+	for i, element := range elements {
+		element.SetIndex(i)
+	}
+
+	return elements
+}
+
+func isASCIIPunct(ch rune) bool {
+	return ch < utf8.RuneSelf && unicode.IsPunct(ch) || strings.ContainsRune("$+<=>^`|~", ch)
+}
+
+func isWordRune(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
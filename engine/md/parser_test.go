@@ -0,0 +1,176 @@
+package md
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseElements(t *testing.T, source string) []Element {
+	document, err := Parse(source)
+	require.NoError(t, err)
+	require.Len(t, document.GetSections(), 1)
+
+	return document.GetSections()[0].GetElements()
+}
+
+func TestParse_headers(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		level  HeaderLevel
+		text   string
+	}{
+		{"atx", "# Title", HeaderLevelOne, "Title"},
+		{"atx closing sequence", "### Title ###", HeaderLevelThree, "Title"},
+		{"atx escaped", `## 1\. Escaped \*text\*`, HeaderLevelTwo, "1. Escaped *text*"},
+		{"setext equals", "Title\n=====", HeaderLevelOne, "Title"},
+		{"setext dashes", "Title\n---", HeaderLevelTwo, "Title"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elements := parseElements(t, tt.source)
+			require.Len(t, elements, 1)
+			header, ok := elements[0].(*Header)
+			require.True(t, ok)
+			assert.Equal(t, tt.level, header.GetLevel())
+			assert.Equal(t, tt.text, header.GetText())
+		})
+	}
+}
+
+func TestParse_blocks(t *testing.T) {
+	source := "<a name=\"api.Message\"></a>\n" +
+		"Paragraph *italic* text\nsecond line\n\n" +
+		"***\n\n" +
+		"```go\nfunc main() {}\n```\n\n" +
+		"> quoted **bold**\n> # quoted header\n"
+
+	elements := parseElements(t, source)
+	require.Len(t, elements, 5)
+	for i, element := range elements {
+		assert.Equal(t, i, element.GetIndex())
+	}
+
+	assert.Equal(t, "api.Message", elements[0].(*HtmlRef).GetName())
+
+	paragraph := elements[1].(*Paragraph)
+	require.Len(t, paragraph.GetElements(), 3)
+	assert.Equal(t, "Paragraph ", paragraph.GetElements()[0].(*Text).GetText())
+	assert.Equal(t, "italic", paragraph.GetElements()[1].(*Text).GetText())
+	assert.Equal(t, TextEmphasisItalic, paragraph.GetElements()[1].(*Text).GetEmphasis())
+	assert.Equal(t, "text\nsecond line", paragraph.GetElements()[2].(*Text).GetText())
+
+	assert.IsType(t, &Rule{}, elements[2])
+
+	codeblock := elements[3].(*Codeblock)
+	assert.Equal(t, "go", codeblock.GetLanguage())
+	assert.Equal(t, "func main() {}", codeblock.GetText())
+
+	blockquote := elements[4].(*Blockquote)
+	require.Len(t, blockquote.GetElements(), 2)
+	assert.IsType(t, &Paragraph{}, blockquote.GetElements()[0])
+	assert.Equal(t, "quoted header", blockquote.GetElements()[1].(*Header).GetText())
+}
+
+func TestParse_unclosedCodeblock(t *testing.T) {
+	_, err := Parse("text\n\n~~~\ncode")
+	assert.ErrorContains(t, err, "line 3")
+}
+
+func TestParse_lists(t *testing.T) {
+	source := "* first\n" +
+		"     * nested [link](#target)\n" +
+		"         1. deep\n" +
+		"* second\n"
+
+	elements := parseElements(t, source)
+	require.Len(t, elements, 1)
+	list := elements[0].(*List)
+	assert.False(t, list.IsOrdered())
+	require.Len(t, list.GetEntries(), 2)
+	assert.Equal(t, "first", list.GetEntries()[0].GetElement().(*Text).GetText())
+	assert.Equal(t, "second", list.GetEntries()[1].GetElement().(*Text).GetText())
+
+	require.Len(t, list.GetEntries()[0].GetElements(), 1)
+	nested := list.GetEntries()[0].GetElements()[0].(*List)
+	assert.Equal(t, 1, nested.GetLevel())
+	require.Len(t, nested.GetEntries(), 1)
+	paragraph := nested.GetEntries()[0].GetElement().(*Paragraph)
+	require.Len(t, paragraph.GetElements(), 2)
+	link := paragraph.GetElements()[1].(*Link)
+	assert.Equal(t, "link", link.GetText())
+	assert.Equal(t, "#target", link.GetUrl())
+
+	deep := nested.GetEntries()[0].GetElements()[0].(*List)
+	assert.True(t, deep.IsOrdered())
+	assert.Equal(t, 2, deep.GetLevel())
+	assert.Equal(t, "deep", deep.GetEntries()[0].GetElement().(*Text).GetText())
+}
+
+func TestParse_table(t *testing.T) {
+	source := "| Name | Value | Note |\n" +
+		"|:---|---:|:----:|\n" +
+		"| `a\\|b` | 1 | line<br>break |\n" +
+		"| c | 2 |\n"
+
+	elements := parseElements(t, source)
+	require.Len(t, elements, 1)
+	table := elements[0].(*Table)
+	assert.Equal(t, 2, table.GetRows())
+	columns := table.GetColumns()
+	require.Len(t, columns, 3)
+	assert.Equal(t, "Name", columns[0].GetName())
+	assert.Equal(t, ColumnAlignmentLeft, columns[0].GetAlignment())
+	assert.Equal(t, ColumnAlignmentRight, columns[1].GetAlignment())
+	assert.Equal(t, ColumnAlignmentCenter, columns[2].GetAlignment())
+
+	code := columns[0].GetRows()[0].GetElements()[0].(*Text)
+	assert.Equal(t, "a|b", code.GetText())
+	assert.Equal(t, TextEmphasisCode, code.GetEmphasis())
+	assert.Equal(t, "line\nbreak", columns[2].GetRows()[0].GetElements()[0].(*Text).GetText())
+	assert.Empty(t, columns[2].GetRows()[1].GetElements())
+}
+
+func TestParse_inline(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		texts    []string
+		emphasis []TextEmphasis
+	}{
+		{"bold", "**bold** text", []string{"bold", "text"}, []TextEmphasis{TextEmphasisBold, TextEmphasisNormal}},
+		{"bold italic", "___both___", []string{"both"}, []TextEmphasis{TextEmphasisBoldItalic}},
+		{"intraword underscore", "snake_case_name", []string{"snake_case_name"}, []TextEmphasis{TextEmphasisNormal}},
+		{"code span", "run `` a`b `` now", []string{"run ", "a`b", "now"}, []TextEmphasis{TextEmphasisNormal, TextEmphasisCode, TextEmphasisNormal}},
+		{"unmatched delimiter", "2 * 3", []string{"2 * 3"}, []TextEmphasis{TextEmphasisNormal}},
+		{"strikethrough", "~~old~~ new", []string{"old", "new"}, []TextEmphasis{TextEmphasisStrikethrough, TextEmphasisNormal}},
+		{"single tilde", "~5 items", []string{"~5 items"}, []TextEmphasis{TextEmphasisNormal}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elements := parseInline(tt.source)
+			require.Len(t, elements, len(tt.texts))
+			for i, element := range elements {
+				assert.Equal(t, tt.texts[i], element.(*Text).GetText())
+				assert.Equal(t, tt.emphasis[i], element.(*Text).GetEmphasis())
+			}
+		})
+	}
+
+	t.Run("image with title", func(t *testing.T) {
+		elements := parseInline(`![alt text](img/logo.png "Logo")`)
+		require.Len(t, elements, 1)
+		image := elements[0].(*Image)
+		assert.Equal(t, "alt text", image.GetText())
+		assert.Equal(t, "img/logo.png", image.GetUrl())
+		assert.Equal(t, "Logo", image.GetTitle())
+	})
+}
+
+func TestSlug(t *testing.T) {
+	assert.Equal(t, "getting-started", Slug("Getting Started"))
+	assert.Equal(t, "v12-api_reference", Slug("v1.2 API_Reference!"))
+	assert.Equal(t, "добро-пожаловать", Slug("Добро пожаловать"))
+}
@@ -0,0 +1,46 @@
+package md
+
+/*
+Walk calls fn for every element of the document in order, including elements nested into paragraphs, blockquotes,
+list entries and table rows.
+*/
+func Walk(document *Document, fn func(element Element)) {
+	for _, section := range document.GetSections() {
+		for _, element := range section.GetElements() {
+			walkElement(element, fn)
+		}
+	}
+}
+
+func walkElement(element Element, fn func(element Element)) {
+	if element == nil {
+		return
+	}
+	fn(element)
+
+	switch e := element.(type) {
+	case *Paragraph:
+		for _, child := range e.GetElements() {
+			walkElement(child, fn)
+		}
+	case *Blockquote:
+		for _, child := range e.GetElements() {
+			walkElement(child, fn)
+		}
+	case *List:
+		for _, entry := range e.GetEntries() {
+			walkElement(entry.GetElement(), fn)
+			for _, child := range entry.GetElements() {
+				walkElement(child, fn)
+			}
+		}
+	case *Table:
+		for _, column := range e.GetColumns() {
+			for _, row := range column.GetRows() {
+				for _, child := range row.GetElements() {
+					walkElement(child, fn)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type DiagramMode string
+
+const (
+	DiagramModeNone   DiagramMode = ""
+	DiagramModeFile   DiagramMode = "file"
+	DiagramModeHeader DiagramMode = "header"
+)
+
+func ParseDiagramMode(mode string) (DiagramMode, error) {
+	switch DiagramMode(strings.ToLower(mode)) {
+	case DiagramModeNone:
+		return DiagramModeNone, nil
+	case DiagramModeFile:
+		return DiagramModeFile, nil
+	case DiagramModeHeader:
+		return DiagramModeHeader, nil
+	}
+
+	return DiagramModeNone, fmt.Errorf("unsupported diagram mode: '%s', supported modes are: file, header", mode)
+}
+
+// SetDiagrams enables mermaid class diagrams of the messages, placed per file or per @header group
+func (g *MDGenerator) SetDiagrams(mode DiagramMode) {
+	g.diagrams = mode
+}
+
+// diagramTypes indexes the documented messages and enums of all files by their full names
+type diagramTypes struct {
+	messages map[string]*Message
+	enums    map[string]*Enum
+}
+
+func newDiagramTypes(parsedFiles []ParsedFile) diagramTypes {
+	types := diagramTypes{messages: make(map[string]*Message), enums: make(map[string]*Enum)}
+	for _, file := range parsedFiles {
+		for _, entry := range file.entries {
+			switch {
+			case entry.t == EntryTypeMessage && entry.msg.m != nil:
+				types.messages[entry.msg.m.GetFullName()] = entry.msg
+			case entry.t == EntryTypeEnum && entry.enum.e != nil:
+				types.enums[entry.enum.e.GetFullName()] = entry.enum
+			}
+		}
+	}
+
+	return types
+}
+
+// diagram adds mermaid class and message flow diagrams of the messages to the section, nothing is added if there are no messages
+func (g *MDGenerator) diagram(parsedFiles []ParsedFile, messages []*Message, section *md.Section) {
+	if len(messages) == 0 {
+		return
+	}
+
+	types := newDiagramTypes(parsedFiles)
+	section.AddElement(md.NewCodeblockBuilder().Text(classDiagram(messages, types)).Language("mermaid").Build())
+	g.flowDiagram(messages, types, section)
+}
+
+// groupMessages returns the leading messages of the entries, which match the group, enums are skipped
+func groupMessages(entries []Entry, inGroup func(message *Message) bool) []*Message {
+	var messages []*Message
+	for _, entry := range entries {
+		if entry.t != EntryTypeMessage {
+			continue
+		}
+		if !inGroup(entry.msg) {
+			break
+		}
+		if entry.msg.m != nil {
+			messages = append(messages, entry.msg)
+		}
+	}
+
+	return messages
+}
+
+/*
+classDiagram returns mermaid classDiagram of the messages with their fields as members. Message typed fields are drawn as
+composition edges and enum typed fields as associations. Referenced messages outside the diagram are declared without members,
+referenced enums are declared with their values.
+*/
+func classDiagram(messages []*Message, types diagramTypes) string {
+	var declared []string
+	var referenced []string
+	for _, message := range messages {
+		declared = append(declared, message.m.GetFullName())
+	}
+	for _, message := range messages {
+		for _, field := range message.fields {
+			typeName := strings.TrimPrefix(field.d.GetTypeName(), ".")
+			_, isMessage := types.messages[typeName]
+			_, isEnum := types.enums[typeName]
+			if (isMessage || isEnum) && arrayutils.Contains(typeName, declared) == -1 && arrayutils.Contains(typeName, referenced) == -1 {
+				referenced = append(referenced, typeName)
+			}
+		}
+	}
+	ids := diagramIds(append(append([]string{}, declared...), referenced...))
+
+	var builder strings.Builder
+	builder.WriteString("classDiagram\n")
+	for _, message := range messages {
+		builder.WriteString(fmt.Sprintf("    class %s {\n", ids[message.m.GetFullName()]))
+		for _, field := range message.fields {
+			builder.WriteString(fmt.Sprintf("        +%s %s\n", diagramFieldType(&field, message.m.GetPackage(), ids), field.d.GetName()))
+		}
+		builder.WriteString("    }\n")
+	}
+	for _, typeName := range referenced {
+		if enum, ok := types.enums[typeName]; ok {
+			builder.WriteString(fmt.Sprintf("    class %s {\n", ids[typeName]))
+			builder.WriteString("        <<enumeration>>\n")
+			for _, value := range enum.values {
+				builder.WriteString("        " + value.d.GetName() + "\n")
+			}
+			builder.WriteString("    }\n")
+		} else {
+			builder.WriteString(fmt.Sprintf("    class %s\n", ids[typeName]))
+		}
+	}
+
+	for _, message := range messages {
+		for _, field := range message.fields {
+			target, ok := ids[strings.TrimPrefix(field.d.GetTypeName(), ".")]
+			if !ok {
+				continue
+			}
+			owner := ids[message.m.GetFullName()]
+			repeated := field.d.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+			switch {
+			case field.d.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+				builder.WriteString(fmt.Sprintf("    %s --> %s : %s\n", owner, target, field.d.GetName()))
+			case repeated:
+				builder.WriteString(fmt.Sprintf("    %s \"1\" *-- \"*\" %s : %s\n", owner, target, field.d.GetName()))
+			default:
+				builder.WriteString(fmt.Sprintf("    %s *-- %s : %s\n", owner, target, field.d.GetName()))
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+// diagramIds returns class names of the types, short names are used unless they collide within the diagram
+func diagramIds(fullNames []string) map[string]string {
+	counts := make(map[string]int)
+	for _, fullName := range fullNames {
+		counts[shortName(fullName)]++
+	}
+
+	ids := make(map[string]string)
+	for _, fullName := range fullNames {
+		if counts[shortName(fullName)] > 1 {
+			ids[fullName] = strings.ReplaceAll(fullName, ".", "_")
+		} else {
+			ids[fullName] = shortName(fullName)
+		}
+	}
+
+	return ids
+}
+
+func shortName(fullName string) string {
+	return fullName[strings.LastIndex(fullName, ".")+1:]
+}
+
+// diagramFieldType returns class name of the diagram types or the field type as the fields table shows it, repeated fields use mermaid generics
+func diagramFieldType(field *MessageField, pkg string, ids map[string]string) string {
+	typeName, ok := ids[strings.TrimPrefix(field.d.GetTypeName(), ".")]
+	if !ok {
+		typeName = strings.TrimPrefix(pbTypeToString(field.d), pkg+".")
+	}
+	if typeName == "[]byte" {
+		// mermaid doesn't accept brackets in member types
+		typeName = "bytes"
+	}
+	if field.d.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		return "List~" + typeName + "~"
+	}
+
+	return typeName
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassDiagram(t *testing.T) {
+	entries, err := NewDescriptorParser(splitTestRequest(t)).Parse()
+	require.NoError(t, err)
+	types := newDiagramTypes(entries)
+
+	t.Run("referenced types are declared", func(t *testing.T) {
+		expected := "classDiagram\n" +
+			"    class Order {\n" +
+			"        +Money price\n" +
+			"        +Item item\n" +
+			"    }\n" +
+			"    class Item {\n" +
+			"        +string name\n" +
+			"    }\n" +
+			"    class Money\n" +
+			"    Order *-- Money : price\n" +
+			"    Order *-- Item : item\n"
+		assert.Equal(t, expected, classDiagram(groupMessages(entries[1].entries, func(*Message) bool { return true }), types))
+	})
+
+	t.Run("enums are associations", func(t *testing.T) {
+		result := classDiagram([]*Message{types.messages["common.Money"]}, types)
+		assert.Contains(t, result, "    class Currency {\n        <<enumeration>>\n")
+		assert.Contains(t, result, "    Money --> Currency : currency\n")
+	})
+
+	t.Run("colliding names are qualified", func(t *testing.T) {
+		assert.Equal(t, map[string]string{"a.Item": "a_Item", "b.Item": "b_Item", "b.Order": "Order"},
+			diagramIds([]string{"a.Item", "b.Item", "b.Order"}))
+	})
+}
+
+func TestMDGenerator_diagrams(t *testing.T) {
+	request, err := testRequest()
+	require.NoError(t, err)
+	entries, err := NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		mode     DiagramMode
+		diagrams int
+	}{
+		{DiagramModeNone, 0},
+		{DiagramModeFile, 1},
+		{DiagramModeHeader, 3},
+	} {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			generator := NewMDGenerator(NewCodegenerator())
+			generator.SetDiagrams(tc.mode)
+			document, err := generator.Generate(entries)
+			require.NoError(t, err)
+			result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), document)
+			require.NoError(t, err)
+			assert.Equal(t, tc.diagrams, strings.Count(result, "```mermaid\nclassDiagram\n"))
+		})
+	}
+}
+
+func TestParseDiagramMode(t *testing.T) {
+	mode, err := ParseDiagramMode("Header")
+	assert.NoError(t, err)
+	assert.Equal(t, DiagramModeHeader, mode)
+	_, err = ParseDiagramMode("package")
+	assert.Error(t, err)
+}
@@ -0,0 +1,322 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+const OpenAPIVersion = "3.0.3"
+
+type OpenAPIDocument struct {
+	OpenAPI    string                      `json:"openapi" yaml:"openapi"`
+	Info       OpenAPIInfo                 `json:"info" yaml:"info"`
+	Tags       []OpenAPITag                `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Paths      map[string]*OpenAPIPathItem `json:"paths" yaml:"paths"`
+	Components OpenAPIComponents           `json:"components" yaml:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+type OpenAPITag struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type OpenAPIPathItem struct {
+	Get    *OpenAPIOperation `json:"get,omitempty" yaml:"get,omitempty"`
+	Put    *OpenAPIOperation `json:"put,omitempty" yaml:"put,omitempty"`
+	Post   *OpenAPIOperation `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete *OpenAPIOperation `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Patch  *OpenAPIOperation `json:"patch,omitempty" yaml:"patch,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId" yaml:"operationId"`
+	Summary     string                     `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                     `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses" yaml:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name        string      `json:"name" yaml:"name"`
+	In          string      `json:"in" yaml:"in"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool        `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *JSONSchema `json:"schema" yaml:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content" yaml:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description" yaml:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema   *JSONSchema               `json:"schema" yaml:"schema"`
+	Examples map[string]OpenAPIExample `json:"examples,omitempty" yaml:"examples,omitempty"`
+}
+
+type OpenAPIExample struct {
+	Ref     string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Value   any    `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas  map[string]*JSONSchema    `json:"schemas" yaml:"schemas"`
+	Examples map[string]OpenAPIExample `json:"examples,omitempty" yaml:"examples,omitempty"`
+}
+
+func (d *OpenAPIDocument) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+func (d *OpenAPIDocument) YAML() ([]byte, error) {
+	return marshalYaml(d)
+}
+
+type OpenAPIGenerator struct {
+	codegen *Codegenerator
+	info    OpenAPIInfo
+}
+
+func NewOpenAPIGenerator(codegen *Codegenerator, info OpenAPIInfo) *OpenAPIGenerator {
+	return &OpenAPIGenerator{codegen: codegen, info: info}
+}
+
+func (g *OpenAPIGenerator) Generate(parsedFiles []ParsedFile) (*OpenAPIDocument, error) {
+	schemas := newSchemaBuilder(parsedFiles, g.codegen)
+	result := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    g.info,
+		Paths:   make(map[string]*OpenAPIPathItem),
+		Components: OpenAPIComponents{
+			Schemas:  schemas.schemas(),
+			Examples: make(map[string]OpenAPIExample),
+		},
+	}
+	if result.Info.Title == "" {
+		result.Info.Title = "API"
+		if len(parsedFiles) > 0 && parsedFiles[0].Title() != "" {
+			result.Info.Title = parsedFiles[0].Title()
+		}
+	}
+	if result.Info.Version == "" {
+		result.Info.Version = "1.0.0"
+	}
+
+	for name, schema := range result.Components.Schemas {
+		if schema.Example != nil {
+			result.Components.Examples[name] = OpenAPIExample{Summary: name + " example", Value: schema.Example}
+		}
+	}
+
+	for _, file := range parsedFiles {
+		for _, service := range file.services {
+			result.Tags = append(result.Tags, OpenAPITag{Name: service.s.GetName(), Description: service.description})
+			for _, method := range service.methods {
+				if err := g.method(result, schemas, &service, &method); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	sort.SliceStable(result.Tags, func(i, j int) bool {
+		return result.Tags[i].Name < result.Tags[j].Name
+	})
+
+	return result, nil
+}
+
+func (g *OpenAPIGenerator) method(doc *OpenAPIDocument, schemas *schemaBuilder, service *Service, method *ServiceMethod) error {
+	input := trimTypeName(method.d.GetInputType())
+	output := trimTypeName(method.d.GetOutputType())
+	for _, typeName := range []string{input, output} {
+		g.externalSchema(doc, schemas, typeName, method)
+	}
+
+	rules := []HttpRule{
+		*NewHttpRule(http.MethodPost, fmt.Sprintf("/%s/%s", service.s.GetFullName(), method.d.GetName()), "*"),
+	}
+	if rule := method.http.Get(); rule != nil {
+		rules = append([]HttpRule{*rule}, rule.AdditionalBindings()...)
+	}
+
+	for i, rule := range rules {
+		operation := &OpenAPIOperation{
+			OperationID: service.s.GetName() + "_" + method.d.GetName(),
+			Summary:     method.description,
+			Tags:        []string{service.s.GetName()},
+			Responses: map[string]OpenAPIResponse{
+				"200": {
+					Description: "OK",
+					Content:     g.content(doc, output, SchemaRef(output)),
+				},
+			},
+		}
+		if i > 0 {
+			operation.OperationID += fmt.Sprintf("%d", i+1)
+		}
+		switch {
+		case method.d.GetClientStreaming() && method.d.GetServerStreaming():
+			operation.Description = "Bidirectional streaming RPC."
+		case method.d.GetClientStreaming():
+			operation.Description = "Client streaming RPC."
+		case method.d.GetServerStreaming():
+			operation.Description = "Server streaming RPC, response messages are sent as a stream."
+		}
+
+		bound := make(map[string]bool)
+		for _, param := range rule.PathParams() {
+			bound[param] = true
+			operation.Parameters = append(operation.Parameters, g.parameter(schemas, input, param, "path"))
+		}
+
+		switch rule.Body() {
+		case "*":
+			operation.RequestBody = &OpenAPIRequestBody{Required: true, Content: g.content(doc, input, SchemaRef(input))}
+		case "":
+			if msg, ok := schemas.messages[input]; ok {
+				for _, field := range msg.fields {
+					if bound[field.d.GetName()] || field.valueType == ValueTypeStruct {
+						continue
+					}
+					operation.Parameters = append(operation.Parameters, g.parameter(schemas, input, field.d.GetName(), "query"))
+				}
+			}
+		default:
+			body := g.parameter(schemas, input, rule.Body(), "body")
+			operation.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]OpenAPIMediaType{"application/json": {Schema: body.Schema}},
+			}
+		}
+
+		path := rule.TemplatePath()
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &OpenAPIPathItem{}
+			doc.Paths[path] = item
+		}
+		if err := item.set(rule.Method(), operation); err != nil {
+			return fmt.Errorf("method %s: %s", method.d.GetFullName(), err.Error())
+		}
+	}
+
+	return nil
+}
+
+/*
+externalSchema adds a generic object schema of the method type, which isn't among the parsed messages, e.g. google.protobuf.Empty,
+a message of a filtered out file or an @ignore'd one, so the method is exported with a valid reference.
+*/
+func (g *OpenAPIGenerator) externalSchema(doc *OpenAPIDocument, schemas *schemaBuilder, typeName string, method *ServiceMethod) {
+	if _, ok := schemas.messages[typeName]; ok {
+		return
+	}
+	if _, ok := doc.Components.Schemas[typeName]; !ok {
+		log.Warn().Msgf("type '%s' of method %s is not documented, it's exported as an object", typeName, method.d.GetFullName())
+		doc.Components.Schemas[typeName] = &JSONSchema{Type: "object", Description: typeName + " is not a part of the documented files."}
+	}
+}
+
+func (g *OpenAPIGenerator) content(doc *OpenAPIDocument, typeName string, schema *JSONSchema) map[string]OpenAPIMediaType {
+	media := OpenAPIMediaType{Schema: schema}
+	if _, ok := doc.Components.Examples[typeName]; ok {
+		media.Examples = map[string]OpenAPIExample{
+			"default": {Ref: "#/components/examples/" + typeName},
+		}
+	}
+
+	return map[string]OpenAPIMediaType{"application/json": media}
+}
+
+func (g *OpenAPIGenerator) parameter(schemas *schemaBuilder, message, fieldPath, in string) OpenAPIParameter {
+	result := OpenAPIParameter{
+		Name:     fieldPath,
+		In:       in,
+		Required: in == "path",
+		Schema:   &JSONSchema{Type: "string"},
+	}
+
+	msg := schemas.messages[message]
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		if msg == nil {
+			break
+		}
+		var found *MessageField
+		for j := range msg.fields {
+			if msg.fields[j].d.GetName() == name {
+				found = &msg.fields[j]
+			}
+		}
+		if found == nil {
+			break
+		}
+		if i == len(names)-1 {
+			result.Schema = schemas.fieldSchema(found)
+			result.Description = found.description
+			break
+		}
+		msg = schemas.messages[trimTypeName(found.d.GetTypeName())]
+	}
+
+	return result
+}
+
+func (p *OpenAPIPathItem) set(method string, operation *OpenAPIOperation) error {
+	var target **OpenAPIOperation
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		target = &p.Get
+	case http.MethodPut:
+		target = &p.Put
+	case http.MethodPost:
+		target = &p.Post
+	case http.MethodDelete:
+		target = &p.Delete
+	case http.MethodPatch:
+		target = &p.Patch
+	default:
+		return fmt.Errorf("unsupported http method: %s", method)
+	}
+	if *target != nil {
+		return fmt.Errorf("duplicate %s operation, already bound to %s", method, (*target).OperationID)
+	}
+	*target = operation
+
+	return nil
+}
+
+func marshalYaml(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
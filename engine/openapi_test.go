@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"gopkg.in/yaml.v3"
+)
+
+func TestOpenAPIGenerator_Generate(t *testing.T) {
+	request, err := testRequest()
+	require.NoError(t, err)
+	entries, err := NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+
+	document, err := NewOpenAPIGenerator(NewCodegenerator(), OpenAPIInfo{Title: "Test API"}).Generate(entries)
+	require.NoError(t, err)
+
+	assert.Equal(t, OpenAPIVersion, document.OpenAPI)
+	assert.Equal(t, "Test API", document.Info.Title)
+	assert.Equal(t, "1.0.0", document.Info.Version)
+
+	t.Run("rpc paths", func(t *testing.T) {
+		item, ok := document.Paths["/doc_generator_test.AuthService/Token"]
+		require.True(t, ok)
+		require.NotNil(t, item.Post)
+		assert.Equal(t, "AuthService_Token", item.Post.OperationID)
+		assert.Equal(t, "Issues authorization bearer token.", item.Post.Summary)
+		assert.Equal(t, SchemaRefPrefix+"doc_generator_test.TokenRequest", item.Post.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Equal(t, SchemaRefPrefix+"doc_generator_test.TokenResponse", item.Post.Responses["200"].Content["application/json"].Schema.Ref)
+
+		streaming := document.Paths["/doc_generator_test.AuthService/ListServers"]
+		require.NotNil(t, streaming)
+		assert.NotEmpty(t, streaming.Post.Description)
+	})
+
+	t.Run("schemas with constraints", func(t *testing.T) {
+		registration := document.Components.Schemas["doc_generator_test.RegistrationRequest"]
+		require.NotNil(t, registration)
+		assert.Equal(t, 10, *registration.Properties["phone"].MaxLength)
+		assert.Equal(t, 13.0, *registration.Properties["token_id"].Minimum)
+		assert.Equal(t, 500.0, *registration.Properties["token_id"].Maximum)
+		assert.Equal(t, "email", registration.Properties["email"].Format)
+
+		servers := document.Components.Schemas["doc_generator_test.ListServersResponse"].Properties["servers"]
+		assert.Equal(t, "array", servers.Type)
+		assert.Equal(t, SchemaRefPrefix+"doc_generator_test.ServerEntry", servers.Items.Ref)
+
+		status := document.Components.Schemas["doc_generator_test.LoginStatus"]
+		assert.Equal(t, []string{"LS_OK", "LS_FAILED", "LS_INVALID_REQUEST"}, status.Enum)
+	})
+
+	t.Run("examples", func(t *testing.T) {
+		assert.Contains(t, document.Components.Examples, "doc_generator_test.TokenRequest")
+		assert.Contains(t, document.Components.Examples, "doc_generator_test.RegistrationRequest")
+		token := document.Paths["/doc_generator_test.AuthService/Token"].Post
+		assert.Equal(t, "#/components/examples/doc_generator_test.TokenRequest", token.RequestBody.Content["application/json"].Examples["default"].Ref)
+	})
+
+	t.Run("marshalling", func(t *testing.T) {
+		content, err := document.JSON()
+		require.NoError(t, err)
+		assert.True(t, json.Valid(content))
+
+		content, err = document.YAML()
+		require.NoError(t, err)
+		var parsed map[string]any
+		require.NoError(t, yaml.Unmarshal(content, &parsed))
+		assert.Equal(t, OpenAPIVersion, parsed["openapi"])
+	})
+}
+
+func TestOpenAPIGenerator_externalTypes(t *testing.T) {
+	files := diffTestFiles(t, func(_, orders *descriptorpb.FileDescriptorProto) {
+		orders.Service = []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("OrderService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Ping"), InputType: proto.String(".google.protobuf.Empty"), OutputType: proto.String(".google.protobuf.Empty")},
+				{Name: proto.String("Get"), InputType: proto.String(".shop.Item"), OutputType: proto.String(".shop.Order")},
+			},
+		}}
+	})
+
+	document, err := NewOpenAPIGenerator(NewSeededCodegenerator(1), OpenAPIInfo{}).Generate(files)
+	require.NoError(t, err)
+	assert.Equal(t, &JSONSchema{Type: "object", Description: "google.protobuf.Empty is not a part of the documented files."}, document.Components.Schemas["google.protobuf.Empty"])
+
+	ping := document.Paths["/shop.OrderService/Ping"]
+	require.NotNil(t, ping)
+	assert.Equal(t, SchemaRefPrefix+"google.protobuf.Empty", ping.Post.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal(t, SchemaRefPrefix+"google.protobuf.Empty", ping.Post.Responses["200"].Content["application/json"].Schema.Ref)
+	assert.NotNil(t, document.Paths["/shop.OrderService/Get"])
+}
+
+func TestParseHttpRule(t *testing.T) {
+	t.Run("no option", func(t *testing.T) {
+		rule, err := parseHttpRule(&descriptorpb.MethodOptions{})
+		assert.NoError(t, err)
+		assert.Nil(t, rule)
+	})
+	t.Run("get with additional binding", func(t *testing.T) {
+		var binding []byte
+		binding = protowire.AppendTag(binding, 4, protowire.BytesType)
+		binding = protowire.AppendString(binding, "/v1/servers:search")
+		binding = protowire.AppendTag(binding, 7, protowire.BytesType)
+		binding = protowire.AppendString(binding, "*")
+
+		var rule []byte
+		rule = protowire.AppendTag(rule, 2, protowire.BytesType)
+		rule = protowire.AppendString(rule, "/v1/{host=hosts/*}/servers")
+		rule = protowire.AppendTag(rule, 11, protowire.BytesType)
+		rule = protowire.AppendBytes(rule, binding)
+
+		parsed, err := parseHttpRule(testMethodOptions(rule))
+		require.NoError(t, err)
+		require.NotNil(t, parsed)
+		assert.Equal(t, http.MethodGet, parsed.Method())
+		assert.Equal(t, "/v1/{host}/servers", parsed.TemplatePath())
+		assert.Equal(t, []string{"host"}, parsed.PathParams())
+		require.Len(t, parsed.AdditionalBindings(), 1)
+		assert.Equal(t, http.MethodPost, parsed.AdditionalBindings()[0].Method())
+		assert.Equal(t, "*", parsed.AdditionalBindings()[0].Body())
+	})
+	t.Run("custom pattern", func(t *testing.T) {
+		var custom []byte
+		custom = protowire.AppendTag(custom, 1, protowire.BytesType)
+		custom = protowire.AppendString(custom, "HEAD")
+		custom = protowire.AppendTag(custom, 2, protowire.BytesType)
+		custom = protowire.AppendString(custom, "/v1/ping")
+
+		var rule []byte
+		rule = protowire.AppendTag(rule, 8, protowire.BytesType)
+		rule = protowire.AppendBytes(rule, custom)
+
+		parsed, err := parseHttpRule(testMethodOptions(rule))
+		require.NoError(t, err)
+		assert.Equal(t, "HEAD", parsed.Method())
+		assert.Equal(t, "/v1/ping", parsed.Path())
+	})
+	t.Run("missing pattern", func(t *testing.T) {
+		var rule []byte
+		rule = protowire.AppendTag(rule, 7, protowire.BytesType)
+		rule = protowire.AppendString(rule, "*")
+
+		_, err := parseHttpRule(testMethodOptions(rule))
+		assert.Error(t, err)
+	})
+}
+
+func testMethodOptions(rule []byte) *descriptorpb.MethodOptions {
+	var raw []byte
+	raw = protowire.AppendTag(raw, HttpRuleExtension, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, rule)
+	options := &descriptorpb.MethodOptions{}
+	options.ProtoReflect().SetUnknown(raw)
+
+	return options
+}
@@ -33,6 +33,15 @@ const AutocodeMinMarker = "min"
 const AutocodeMaxLengthMarker = "len"
 const AutocodeValueMarker = "val"
 const AutocodeTypeMarker = "type"
+const AutocodeClaimMarker = "claim"
+const AutocodePatternMarker = "pattern"
+const PublishMarker = "publish"
+const SubscribeMarker = "subscribe"
+const ResponseMarker = "response"
+const DeprecatedMarker = "deprecated"
+const SinceMarker = "since"
+const VisibilityMarker = "visibility"
+const InternalMarker = "internal"
 
 const CodeSyntaxPattern = "(" + CodeMarker + "(\\[[a-zA-Z]+\\])" + "|" + AutocodeMarker + ")"
 
@@ -52,6 +61,8 @@ type FieldFlags struct {
 	min, max   opt.Opt[float64]
 	value      opt.Opt[string]
 	customType opt.Opt[ValueType]
+	pattern    opt.Opt[string]
+	claims     []arrayutils.Pair[string, string]
 	other      []string
 }
 
@@ -75,11 +86,25 @@ func (a FieldFlags) GetCustomType() opt.Opt[ValueType] {
 	return a.customType
 }
 
+func (a FieldFlags) GetPattern() opt.Opt[string] {
+	return a.pattern
+}
+
+// GetClaims returns JWT claims set with @claim=<name>:<value> in the declaration order.
+func (a FieldFlags) GetClaims() []arrayutils.Pair[string, string] {
+	return a.claims
+}
+
 type ParsedFile struct {
 	index    int
 	filename string
+	pkg      string
 	title    string
 	entries  []Entry
+	services []Service
+	imports  []string
+	// visibility lists the audiences the file is documented for, it's documented for everyone if empty
+	visibility []string
 }
 
 func (p ParsedFile) Index() int {
@@ -94,6 +119,10 @@ func (p ParsedFile) Title() string {
 	return p.title
 }
 
+func (p ParsedFile) Package() string {
+	return p.pkg
+}
+
 type Entry struct {
 	index int
 	t     EntryType
@@ -109,6 +138,9 @@ type Text struct {
 
 type Enum struct {
 	description string
+	deprecated  opt.Opt[string]
+	since       string
+	visibility  []string
 	e           *protokit.EnumDescriptor
 	values      []EnumField
 	flags       []string
@@ -116,6 +148,9 @@ type Enum struct {
 
 type EnumField struct {
 	description string
+	deprecated  opt.Opt[string]
+	since       string
+	visibility  []string
 	flags       []string
 
 	d *protokit.EnumValueDescriptor
@@ -127,6 +162,13 @@ type Message struct {
 
 	header      string
 	description string
+	// response is the message name set with @response=<Message>, which is sent in reply to this one
+	response string
+	// deprecated holds the deprecation reason, which is empty if none is given
+	deprecated opt.Opt[string]
+	// since is the API version set with @since=<version>, which the message appeared in
+	since      string
+	visibility []string
 
 	m       *protokit.Descriptor
 	fields  []MessageField
@@ -134,10 +176,29 @@ type Message struct {
 	flags   []string
 }
 
+type Service struct {
+	description string
+	s           *protokit.ServiceDescriptor
+	methods     []ServiceMethod
+	flags       []string
+}
+
+type ServiceMethod struct {
+	description string
+	deprecated  opt.Opt[string]
+	since       string
+	d           *protokit.MethodDescriptor
+	http        opt.Opt[HttpRule]
+	flags       []string
+}
+
 type MessageField struct {
 	valueType   ValueType
 	flags       opt.Opt[FieldFlags]
 	description string
+	deprecated  opt.Opt[string]
+	since       string
+	visibility  []string
 
 	d     *protokit.FieldDescriptor
 	m     *protokit.Descriptor
@@ -171,6 +232,8 @@ type DescriptorParser struct {
 	document md.Document
 
 	readOffsets map[string]int
+	// valueTypes are the accepted @type values in addition to the built-in ones
+	valueTypes []ValueType
 }
 
 func NewDescriptorParser(request *plugingo.CodeGeneratorRequest) *DescriptorParser {
@@ -211,6 +274,31 @@ func NewDescriptorParser(request *plugingo.CodeGeneratorRequest) *DescriptorPars
 	}
 }
 
+/*
+NewDescriptorSetParser returns the parser of all files of the descriptor set, except google.protobuf ones. Sources are not
+available, so annotations are read from the comments only, which requires the set to be built with --include_source_info.
+*/
+func NewDescriptorSetParser(set *descriptorpb.FileDescriptorSet) *DescriptorParser {
+	request := &plugingo.CodeGeneratorRequest{ProtoFile: set.GetFile()}
+	for _, file := range set.GetFile() {
+		if !strings.HasPrefix(file.GetName(), "google/protobuf/") {
+			request.FileToGenerate = append(request.FileToGenerate, file.GetName())
+		}
+	}
+
+	return &DescriptorParser{
+		descriptors:  protokit.ParseCodeGenRequest(request),
+		matchedFiles: make(map[string]*os.File),
+		readOffsets:  make(map[string]int),
+		payload:      make(map[string]string),
+	}
+}
+
+// SetValueTypes sets the custom @type values, e.g. Codegenerator.ValueTypes() with registered generators, other types are rejected
+func (p *DescriptorParser) SetValueTypes(types ...ValueType) {
+	p.valueTypes = types
+}
+
 func (p *DescriptorParser) Parse() ([]ParsedFile, error) {
 	result := make([]ParsedFile, 0)
 	sort.Slice(p.descriptors, func(i, j int) bool {
@@ -283,11 +371,29 @@ func (p *DescriptorParser) Parse() ([]ParsedFile, error) {
 			enumInd++
 		}
 
+		services := make([]Service, 0)
+		for _, service := range descriptor.GetServices() {
+			svc, err := p.parseService(service)
+			if err != nil {
+				return nil, err
+			}
+			if arrayutils.Contains(IgnoreMarker, svc.flags) != -1 {
+				log.Warn().Msgf("ignoring service '%s'", service.GetName())
+				continue
+			}
+
+			services = append(services, *svc)
+		}
+
 		parsedFile := ParsedFile{
-			index:    i,
-			filename: descriptor.GetName(),
-			title:    title,
-			entries:  entries,
+			index:      i,
+			filename:   descriptor.GetName(),
+			pkg:        descriptor.GetPackage(),
+			title:      title,
+			entries:    entries,
+			services:   services,
+			imports:    descriptor.GetDependency(),
+			visibility: parseVisibility(append(parseCommentFlags(descriptor.GetSyntaxComments()), parseCommentFlags(descriptor.GetPackageComments())...)),
 		}
 		result = append(result, parsedFile)
 	}
@@ -303,6 +409,10 @@ func (p *DescriptorParser) parseMessage(descriptor *protokit.Descriptor, header
 	}
 	result.description = p.parseMessageDescription(descriptor)
 	result.flags = p.parseMessageFlags(descriptor)
+	result.response, _ = flagValue(result.flags, ResponseMarker)
+	result.deprecated = parseDeprecation(result.flags, descriptor.GetOptions().GetDeprecated())
+	result.since, _ = flagValue(result.flags, SinceMarker)
+	result.visibility = parseVisibility(result.flags)
 	autocode, err := p.parseAutocode(descriptor)
 	if err != nil {
 		return nil, wrapMsgErr(descriptor, err)
@@ -354,6 +464,9 @@ func (p *DescriptorParser) parseEnum(descriptor *protokit.EnumDescriptor) (*Enum
 	}
 	result.description = p.parseEnumDescription(descriptor)
 	result.flags = p.parseEnumFlags(descriptor)
+	result.deprecated = parseDeprecation(result.flags, descriptor.GetOptions().GetDeprecated())
+	result.since, _ = flagValue(result.flags, SinceMarker)
+	result.visibility = parseVisibility(result.flags)
 	for _, e := range result.e.GetValues() {
 		value, err := p.parseEnumValue(e, descriptor)
 		if err != nil {
@@ -366,6 +479,49 @@ func (p *DescriptorParser) parseEnum(descriptor *protokit.EnumDescriptor) (*Enum
 	return result, nil
 }
 
+func (p *DescriptorParser) parseService(descriptor *protokit.ServiceDescriptor) (*Service, error) {
+	log.Debug().Msgf("parsing service: %s", descriptor.GetName())
+	result := &Service{
+		s:           descriptor,
+		description: parseCommentDescription(descriptor.GetComments()),
+		flags:       parseCommentFlags(descriptor.GetComments()),
+	}
+	for _, m := range descriptor.GetMethods() {
+		method, err := p.parseMethod(m)
+		if err != nil {
+			return nil, wrapServiceErr(descriptor, err)
+		}
+		if arrayutils.Contains(IgnoreMarker, method.flags) != -1 {
+			log.Warn().Msgf("ignoring method '%s'", m.GetName())
+			continue
+		}
+
+		result.methods = append(result.methods, *method)
+	}
+
+	return result, nil
+}
+
+func (p *DescriptorParser) parseMethod(descriptor *protokit.MethodDescriptor) (*ServiceMethod, error) {
+	log.Debug().Msgf("parsing service method: %s", descriptor.GetFullName())
+	rule, err := parseHttpRule(descriptor.GetOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read google.api.http option of method %s: %s", descriptor.GetName(), err.Error())
+	}
+
+	flags := parseCommentFlags(descriptor.GetComments())
+	since, _ := flagValue(flags, SinceMarker)
+
+	return &ServiceMethod{
+		description: parseCommentDescription(descriptor.GetComments()),
+		deprecated:  parseDeprecation(flags, descriptor.GetOptions().GetDeprecated()),
+		since:       since,
+		d:           descriptor,
+		http:        opt.OfNullable(rule),
+		flags:       flags,
+	}, nil
+}
+
 func (p *DescriptorParser) parseField(descriptor *protokit.FieldDescriptor, m *protokit.Descriptor) (*MessageField, error) {
 	log.Debug().Msgf("parsing message field: %s", descriptor.GetFullName())
 	vt := protoToFieldValueType(descriptor)
@@ -375,7 +531,14 @@ func (p *DescriptorParser) parseField(descriptor *protokit.FieldDescriptor, m *p
 		return nil, wrapMsgErr(m, err)
 	}
 
-	return NewMessageField(descriptor, m, description, vt, flags), nil
+	field := NewMessageField(descriptor, m, description, vt, flags)
+	comment, _ := cutQuotedPattern(descriptor.GetComments().String())
+	commentFlags := splitCommentFlags(comment)
+	field.deprecated = parseDeprecation(commentFlags, descriptor.GetOptions().GetDeprecated())
+	field.since, _ = flagValue(commentFlags, SinceMarker)
+	field.visibility = parseVisibility(commentFlags)
+
+	return field, nil
 }
 
 func (p *DescriptorParser) parseEnumValue(descriptor *protokit.EnumValueDescriptor, e *protokit.EnumDescriptor) (*EnumField, error) {
@@ -386,8 +549,13 @@ func (p *DescriptorParser) parseEnumValue(descriptor *protokit.EnumValueDescript
 		return nil, wrapEnumErr(e, err)
 	}
 
+	since, _ := flagValue(flags, SinceMarker)
+
 	return &EnumField{
 		description: description,
+		deprecated:  parseDeprecation(flags, descriptor.GetOptions().GetDeprecated()),
+		since:       since,
+		visibility:  parseVisibility(flags),
 		d:           descriptor,
 		flags:       flags,
 	}, nil
@@ -432,8 +600,14 @@ func (p *DescriptorParser) getPayload(descriptor *protokit.FileDescriptor) (stri
 	if payload, ok := p.payload[descriptor.GetName()]; ok {
 		return payload, nil
 	}
+	file, ok := p.matchedFiles[descriptor.GetName()]
+	if !ok {
+		// descriptor sets are parsed without the sources, so file markers are not available
+		p.payload[descriptor.GetName()] = ""
+		return "", nil
+	}
 
-	readFile, err := io.ReadAll(p.matchedFiles[descriptor.GetName()])
+	readFile, err := io.ReadAll(file)
 	if err != nil {
 		return "", err
 	}
@@ -620,9 +794,9 @@ func (p *DescriptorParser) parseEnumValueDescription(descriptor *protokit.EnumVa
 
 func (p *DescriptorParser) parseFieldFlags(descriptor *protokit.FieldDescriptor) (*FieldFlags, error) {
 	comments := descriptor.GetComments()
-	str := comments.String()
+	str, quotedPattern := cutQuotedPattern(comments.String())
 	var params []string
-	if spl := strings.Split(str, MarkerDelimiter); len(spl) > 1 {
+	if spl := strings.Split(str, MarkerDelimiter); len(spl) > 1 || quotedPattern != nil {
 		spl = arrayutils.Map(spl, func(v *string) string {
 			return strings.TrimSpace(*v)
 		})
@@ -648,12 +822,24 @@ func (p *DescriptorParser) parseFieldFlags(descriptor *protokit.FieldDescriptor)
 		if err != nil {
 			return nil, err
 		}
+		pattern, err := parseAutocodeChar(AutocodePatternMarker, params)
+		if err != nil {
+			return nil, err
+		}
+		if quotedPattern != nil {
+			pattern = *quotedPattern
+		}
+		claims, err := parseClaims(params)
+		if err != nil {
+			return nil, err
+		}
 
 		result := &FieldFlags{
 			maxLength: opt.Opt[int]{},
 			min:       opt.Opt[float64]{},
 			max:       opt.Opt[float64]{},
 			value:     opt.Opt[string]{},
+			claims:    claims,
 		}
 		for _, param := range params {
 			if param != AutocodeMaxMarker &&
@@ -680,8 +866,21 @@ func (p *DescriptorParser) parseFieldFlags(descriptor *protokit.FieldDescriptor)
 			if maperr != nil {
 				return nil, maperr
 			}
+			if supported := p.supportedValueTypes(); arrayutils.Contains(t, supported) == -1 {
+				return nil, fmt.Errorf("unsupported custom type '%s' of field '%s', supported types are: %s", t, descriptor.GetName(), joinValueTypes(supported))
+			}
 			result.customType = opt.Of(t)
 		}
+		if pattern != nil {
+			re, reerr := regexp.Compile(pattern.(string))
+			if reerr != nil {
+				return nil, fmt.Errorf("invalid pattern provided for field '%s': %s", descriptor.GetName(), reerr.Error())
+			}
+			if value != nil && !re.MatchString(value.(string)) {
+				return nil, fmt.Errorf("value '%s' of field '%s' doesn't match pattern '%s'", value.(string), descriptor.GetName(), pattern.(string))
+			}
+			result.pattern = opt.Of(pattern.(string))
+		}
 
 		return result, nil
 	}
@@ -720,6 +919,8 @@ func parseAutocodeChar(marker string, parameters []string) (any, error) {
 				return strconv.ParseFloat(strVal, 64)
 			case AutocodeTypeMarker:
 				return strVal, nil
+			case AutocodePatternMarker:
+				return strVal, nil
 			default:
 				return strconv.ParseFloat(strVal, 64)
 			}
@@ -731,6 +932,23 @@ func parseAutocodeChar(marker string, parameters []string) (any, error) {
 	return nil, nil
 }
 
+func parseClaims(parameters []string) ([]arrayutils.Pair[string, string], error) {
+	var result []arrayutils.Pair[string, string]
+	for _, param := range parameters {
+		if !strings.HasPrefix(param, AutocodeClaimMarker+"=") {
+			continue
+		}
+		claim := strings.Split(strings.TrimPrefix(param, AutocodeClaimMarker+"="), " ")[0]
+		name, value, found := strings.Cut(claim, ":")
+		if !found || name == "" {
+			return nil, fmt.Errorf("failed to read parameter '%s', expected <name>:<value>: %s", AutocodeClaimMarker, param)
+		}
+		result = append(result, *arrayutils.NewPair(name, value))
+	}
+
+	return result, nil
+}
+
 func wrapMsgErr(descriptor *protokit.Descriptor, err error) error {
 	return fmt.Errorf("failed to parse/process message %s\n%s", descriptor.GetName(), err.Error())
 }
@@ -739,6 +957,92 @@ func wrapEnumErr(descriptor *protokit.EnumDescriptor, err error) error {
 	return fmt.Errorf("failed to parse/process enum %s:%s", descriptor.GetName(), err.Error())
 }
 
+func wrapServiceErr(descriptor *protokit.ServiceDescriptor, err error) error {
+	return fmt.Errorf("failed to parse/process service %s\n%s", descriptor.GetName(), err.Error())
+}
+
+func hasFlag(flags []string, marker string) bool {
+	_, found := arrayutils.ContainsPredicate(flags, func(v *string) bool {
+		return strings.Trim(*v, ":*/ \n") == marker
+	})
+
+	return found != nil
+}
+
+// flagValue returns the value of '<marker>=<value>' flag, anything after the value is ignored
+func flagValue(flags []string, marker string) (string, bool) {
+	for _, flag := range flags {
+		value, found := strings.CutPrefix(strings.Trim(flag, ":*/ \n"), marker+"=")
+		if !found {
+			continue
+		}
+		if fields := strings.Fields(value); len(fields) > 0 {
+			return strings.Trim(fields[0], "*/"), true
+		}
+	}
+
+	return "", false
+}
+
+/*
+parseDeprecation returns the reason of '@deprecated[: reason]' flag, the deprecated option gives an empty reason.
+Nothing is returned if neither of them is set.
+*/
+func parseDeprecation(flags []string, option bool) opt.Opt[string] {
+	for _, flag := range flags {
+		flag = strings.Trim(flag, "*/ \n")
+		reason, found := strings.CutPrefix(flag, DeprecatedMarker)
+		if !found || (reason != "" && !strings.HasPrefix(reason, ":") && !strings.HasPrefix(reason, " ")) {
+			continue
+		}
+		return opt.Of(strings.Join(strings.Fields(strings.Trim(reason, ":*/ \n")), " "))
+	}
+	if option {
+		return opt.Of("")
+	}
+
+	return opt.Opt[string]{}
+}
+
+// parseVisibility returns the audiences of '@visibility=<audience>[,<audience>]' flag, '@internal' is the internal audience only
+func parseVisibility(flags []string) []string {
+	if hasFlag(flags, InternalMarker) {
+		return []string{InternalMarker}
+	}
+	value, found := flagValue(flags, VisibilityMarker)
+	if !found {
+		return nil
+	}
+
+	return arrayutils.Filter(strings.Split(value, ","), func(v *string) bool {
+		return *v != ""
+	})
+}
+
+func parseCommentDescription(comments *protokit.Comment) string {
+	desc := ""
+	if spl := strings.Split(comments.String(), MarkerDelimiter); len(spl) > 0 {
+		desc = spl[0]
+	}
+
+	return strings.Trim(strings.ReplaceAll(desc, "\n", " "), "*\n ")
+}
+
+func parseCommentFlags(comments *protokit.Comment) []string {
+	return splitCommentFlags(comments.String())
+}
+
+func splitCommentFlags(comment string) []string {
+	if spl := strings.Split(comment, MarkerDelimiter); len(spl) > 1 {
+		spl = arrayutils.Map(spl, func(v *string) string {
+			return strings.TrimSpace(*v)
+		})
+		return spl[1:]
+	}
+
+	return nil
+}
+
 func protoToFieldValueType(d *protokit.FieldDescriptor) ValueType {
 	switch d.GetType() {
 	case descriptorpb.FieldDescriptorProto_TYPE_INT64:
@@ -794,31 +1098,51 @@ func protoToFieldValueType(d *protokit.FieldDescriptor) ValueType {
 	return ValueTypeString
 }
 
+var valueTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// quotedPatternRegexp matches @pattern=`<regex>`, the quoted regex may contain spaces and '@', but not backticks
+var quotedPatternRegexp = regexp.MustCompile(MarkerDelimiter + AutocodePatternMarker + "=`([^`]*)`")
+
+// cutQuotedPattern returns the comment without the quoted @pattern and the pattern, which is nil if it's not quoted
+func cutQuotedPattern(comment string) (string, *string) {
+	match := quotedPatternRegexp.FindStringSubmatchIndex(comment)
+	if match == nil {
+		return comment, nil
+	}
+	pattern := comment[match[2]:match[3]]
+
+	return comment[:match[0]] + comment[match[1]:], &pattern
+}
+
+/*
+mapStringToValueType accepts any identifier-like type name, so custom types can be served by registered value generators.
+The parser checks the type against the built-in types and the ones set with SetValueTypes.
+*/
+func (p *DescriptorParser) supportedValueTypes() []ValueType {
+	types := builtinValueTypes()
+	for _, t := range p.valueTypes {
+		if arrayutils.Contains(t, types) == -1 {
+			types = append(types, t)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+
+	return types
+}
+
+func joinValueTypes(types []ValueType) string {
+	return strings.Join(arrayutils.Map(types, func(t *ValueType) string {
+		return string(*t)
+	}), ", ")
+}
+
 func mapStringToValueType(customType string) (ValueType, error) {
-	switch strings.ToLower(customType) {
-	case "int":
-		return ValueTypeInt, nil
-	case "uint":
-		return ValueTypeUInt, nil
-	case "float":
-		return ValueTypeFloat, nil
-	case "bool":
-		return ValueTypeBool, nil
-	case "string":
-		return ValueTypeString, nil
-	case "enum":
-		return ValueTypeEnum, nil
-	case "uuid":
-		return ValueTypeUUID, nil
-	case "struct":
-		return ValueTypeStruct, nil
-	case "email":
-		return ValueTypeEmail, nil
-	case "phone":
-		return ValueTypePhone, nil
-	case "password":
-		return ValueTypePassword, nil
-	default:
-		return 0, fmt.Errorf("unknown custom type provided: %s", customType)
+	t := strings.ToLower(strings.TrimSpace(customType))
+	if !valueTypePattern.MatchString(t) {
+		return "", fmt.Errorf("invalid custom type provided: %s", customType)
 	}
+
+	return ValueType(t), nil
 }
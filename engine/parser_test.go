@@ -1,10 +1,15 @@
 package engine
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/pseudomuto/protokit"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/kordax/basic-utils/opt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 func TestDescriptorParser_ParseHeader(t *testing.T) {
@@ -30,7 +35,162 @@ func TestDescriptorParser_DescriptorToDocument(t *testing.T) {
 	assert.NotEmpty(t, document)
 
 	renderer := NewMarkdownRenderer(DefaultRenderConfig())
-	marshalled, err := renderer.Render(document)
+	marshalled, err := RenderString(renderer, document)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, marshalled)
 }
+
+func TestMDGenerator_Prefix(t *testing.T) {
+	request, err := testRequest()
+	assert.NoError(t, err)
+	entries, err := NewDescriptorParser(request).Parse()
+	assert.NoError(t, err)
+
+	generator := NewMDGenerator(NewCodegenerator())
+	assert.NoError(t, generator.SetPrefix("# Overview\n\nIntro.\n\n## Usage\n\n### Usage\n\n# Overview\n"))
+	document, err := generator.Generate(entries)
+	assert.NoError(t, err)
+
+	renderer := NewMarkdownRenderer(DefaultRenderConfig())
+	marshalled, err := RenderString(renderer, document)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(marshalled, "# Overview\n\nIntro.\n"))
+	assert.Contains(t, marshalled, "* Table Of Contents\n"+
+		"     * [Overview](#overview)\n"+
+		"          * [Usage](#usage)\n"+
+		"               * [Usage](#usage-1)\n"+
+		"     * [Overview](#overview-1)\n")
+
+	t.Run("verbatim", func(t *testing.T) {
+		prefix := "[![Build](img)](https://ci)\n\n<p align=\"center\"><img src=\"logo.png\"></p>\n\n" +
+			"**bold _nested_ text** and [ref link][1]\n\n[1]: https://example.com\n"
+		generator := NewMDGenerator(NewCodegenerator())
+		assert.NoError(t, generator.SetPrefix(prefix))
+		document, err := generator.Generate(entries)
+		assert.NoError(t, err)
+		marshalled, err := RenderString(renderer, document)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(marshalled, prefix))
+	})
+
+	t.Run("unclosed code block", func(t *testing.T) {
+		assert.Error(t, NewMDGenerator(NewCodegenerator()).SetPrefix("# Overview\n\n```go\nfunc main() {}\n"))
+	})
+
+	t.Run("repeated anchors", func(t *testing.T) {
+		generator := NewMDGenerator(NewCodegenerator())
+		assert.NoError(t, generator.SetPrefix("# Intro\n\n# Intro-1\n\n# Intro\n\n# Intro\n"))
+		document, err := generator.Generate(entries)
+		require.NoError(t, err)
+		marshalled, err := RenderString(renderer, document)
+		require.NoError(t, err)
+		assert.Contains(t, marshalled, "     * [Intro](#intro)\n"+
+			"     * [Intro-1](#intro-1)\n"+
+			"     * [Intro](#intro-2)\n"+
+			"     * [Intro](#intro-3)\n")
+
+		anchors := pageAnchors(document)
+		for _, anchor := range []string{"intro", "intro-1", "intro-2", "intro-3"} {
+			assert.True(t, anchors[anchor], anchor)
+		}
+	})
+}
+
+func TestParseDeprecation(t *testing.T) {
+	tests := []struct {
+		name   string
+		flags  []string
+		option bool
+		want   opt.Opt[string]
+	}{
+		{"not deprecated", []string{"min=1"}, false, opt.Opt[string]{}},
+		{"annotation", []string{"deprecated"}, false, opt.Of("")},
+		{"annotation with reason", []string{"deprecated: use   price\n instead */"}, false, opt.Of("use price instead")},
+		{"option", nil, true, opt.Of("")},
+		{"annotation reason wins", []string{"deprecated: use price"}, true, opt.Of("use price")},
+		{"other marker", []string{"deprecatedSince=1"}, false, opt.Opt[string]{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseDeprecation(tt.flags, tt.option))
+		})
+	}
+}
+
+func TestMDGenerator_deprecated(t *testing.T) {
+	files := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		money := common.MessageType[0]
+		money.Field[0].Options = &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)}
+		common.EnumType[0].Value = append(common.EnumType[0].Value, &descriptorpb.EnumValueDescriptorProto{
+			Name:    proto.String("EUR"),
+			Number:  proto.Int32(1),
+			Options: &descriptorpb.EnumValueOptions{Deprecated: proto.Bool(true)},
+		})
+		common.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{{
+			Path:            []int32{4, 0},
+			Span:            []int32{3, 0, 20},
+			LeadingComments: proto.String(" Money amount\n @deprecated: use shop.Price\n @autocode[json]\n"),
+		}}}
+	})
+
+	generator := NewMDGenerator(NewSeededCodegenerator(1))
+	generator.SetHideDeprecated(true)
+	document, err := generator.Generate(files)
+	require.NoError(t, err)
+	result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), document)
+	require.NoError(t, err)
+	assert.NotContains(t, result, "[Money](#common-money)")
+	assert.Contains(t, result, "**Deprecated:** use shop.Price\n")
+	assert.Contains(t, result, "| ~~amount~~    | [int64](#scalar-int64)       |       | *Deprecated.*  |")
+	assert.Contains(t, result, "| ~~EUR~~  | *Deprecated.*  |")
+	assert.Contains(t, result, "\"Money\": {\n\t\t\"currency\": \"USD\"\n\t}")
+}
+
+func TestDescriptorParser_SetValueTypes(t *testing.T) {
+	parse := func(comment string, types ...ValueType) ([]ParsedFile, error) {
+		set := &descriptorpb.FileDescriptorSet{}
+		for _, file := range splitTestRequest(t).ProtoFile {
+			set.File = append(set.File, proto.Clone(file).(*descriptorpb.FileDescriptorProto))
+		}
+		// trailing comment of Item.name
+		set.File[1].SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{{
+			Path:             []int32{4, 1, 2, 0},
+			Span:             []int32{4, 2, 20},
+			TrailingComments: proto.String(comment),
+		}}}
+		parser := NewDescriptorSetParser(set)
+		parser.SetValueTypes(types...)
+		return parser.Parse()
+	}
+
+	_, err := parse(" @type=email\n")
+	assert.NoError(t, err)
+
+	_, err = parse(" @type=emial\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported custom type 'emial' of field 'name', supported types are: bool, email, enum, float, int, jwt, password, phone, string, uint, uuid")
+
+	_, err = parse(" @type=iban\n")
+	assert.Error(t, err)
+
+	codegen := NewCodegenerator()
+	codegen.RegisterValueGenerator("iban", ValueGeneratorFunc(generateString))
+	files, err := parse(" @type=iban\n", codegen.ValueTypes()...)
+	require.NoError(t, err)
+	assert.Equal(t, ValueType("iban"), *newApiIndex(files).messages["shop.Item"].fields[0].flags.Get().customType.Get())
+}
+
+func TestMDGenerator_constraintColumns(t *testing.T) {
+	files := diffTestFiles(t, func(common, _ *descriptorpb.FileDescriptorProto) {
+		common.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{
+			{Path: []int32{4, 0, 2, 0}, Span: []int32{4, 2, 20}, TrailingComments: proto.String(" Amount @len=5\n")},
+			{Path: []int32{4, 0, 2, 1}, Span: []int32{5, 2, 20}, TrailingComments: proto.String(" Currency @min=1 @max=3\n")},
+		}}
+	})
+	document, err := NewMDGenerator(NewSeededCodegenerator(1)).Generate(files)
+	require.NoError(t, err)
+	content, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), document)
+	require.NoError(t, err)
+	assert.Contains(t, content, "| **amount**    | [int64](#scalar-int64)       |       | Amount      |           |           |               5 |")
+	assert.Contains(t, content, "| **currency**  | [Currency](#common-currency) |       | Currency    |         1 |         3 |                 |")
+}
@@ -58,6 +58,29 @@ func (g *Generator) GetPassword() string {
 	return g.passwords[g.queries-1]
 }
 
+// Password generates a password from the random source, the same source state produces the same password
+func (g *Generator) Password(r *mrand.Rand) string {
+	password := make([]byte, 0, g.minChar+g.minDec+g.minSpec)
+	for i := 0; i < g.minChar; i++ {
+		if r.Intn(2) == 0 {
+			password = append(password, byte('a'+r.Intn(26)))
+		} else {
+			password = append(password, byte('A'+r.Intn(26)))
+		}
+	}
+	for i := 0; i < g.minDec; i++ {
+		password = append(password, byte('0'+r.Intn(10)))
+	}
+	for i := 0; i < g.minSpec; i++ {
+		password = append(password, SpecialChars[r.Intn(len(SpecialChars))])
+	}
+	r.Shuffle(len(password), func(i, j int) {
+		password[i], password[j] = password[j], password[i]
+	})
+
+	return string(password)
+}
+
 func (g *Generator) genPass() string {
 	builder := strings.Builder{}
 	for i := 0; i < g.minChar; i++ {
@@ -1,6 +1,7 @@
 package password
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,3 +31,11 @@ func TestPasswordGenerator_InstancePassword(t *testing.T) {
 		assert.Equal(t, 1, uCnt, "password expected to be unique: "+u)
 	}
 }
+
+func TestPasswordGenerator_Password(t *testing.T) {
+	gen := NewGenerator(1, 4, 3, 2)
+	password := gen.Password(rand.New(rand.NewSource(42)))
+	assert.Len(t, password, 9)
+	assert.Equal(t, password, gen.Password(rand.New(rand.NewSource(42))))
+	assert.NotEqual(t, password, gen.Password(rand.New(rand.NewSource(43))))
+}
@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	"gitlab.com/kordax/basic-utils/opt"
+)
+
+const patternAttempts = 50
+const patternMaxRepeat = 5
+
+var errPatternNoMatch = errors.New("pattern can't match any string")
+
+// printable ASCII range, character classes are narrowed to it when possible to produce readable examples
+const patternPrintableMin, patternPrintableMax = ' ', '~'
+
+/*
+generatePattern generates a string matching the regular expression, which is not longer than maxLen if set.
+Unbounded repetitions are limited, so the result is a short example rather than a random sample of the language.
+*/
+func generatePattern(pattern string, maxLen opt.Opt[int], r *rand.Rand) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	parsed = parsed.Simplify()
+
+	for attempt := 0; attempt <= patternAttempts; attempt++ {
+		var builder strings.Builder
+		// the last attempt takes the shortest choices to fit into maxLen
+		g := patternGenerator{r: r, shortest: attempt == patternAttempts}
+		if err := g.generate(&builder, parsed); err != nil {
+			return "", err
+		}
+		result := builder.String()
+		if maxLen.Present() && utf8.RuneCountInString(result) > *maxLen.Get() {
+			continue
+		}
+		if re.MatchString(result) {
+			return result, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to generate value matching pattern '%s' within length limits", pattern)
+}
+
+type patternGenerator struct {
+	r        *rand.Rand
+	shortest bool
+}
+
+func (g patternGenerator) generate(builder *strings.Builder, re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return errPatternNoMatch
+	case syntax.OpLiteral:
+		for _, ch := range re.Rune {
+			builder.WriteRune(ch)
+		}
+	case syntax.OpCharClass:
+		ch, err := g.classRune(re.Rune)
+		if err != nil {
+			return err
+		}
+		builder.WriteRune(ch)
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		ch, _ := g.classRune([]rune{'0', '9', 'A', 'Z', 'a', 'z'})
+		builder.WriteRune(ch)
+	case syntax.OpCapture:
+		return g.generate(builder, re.Sub[0])
+	case syntax.OpStar:
+		return g.repeat(builder, re.Sub[0], 0, -1)
+	case syntax.OpPlus:
+		return g.repeat(builder, re.Sub[0], 1, -1)
+	case syntax.OpQuest:
+		return g.repeat(builder, re.Sub[0], 0, 1)
+	case syntax.OpRepeat:
+		return g.repeat(builder, re.Sub[0], re.Min, re.Max)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := g.generate(builder, sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		sub := re.Sub[g.r.Intn(len(re.Sub))]
+		if g.shortest {
+			sub = re.Sub[0]
+			for _, s := range re.Sub[1:] {
+				if minPatternLength(s) < minPatternLength(sub) {
+					sub = s
+				}
+			}
+		}
+		return g.generate(builder, sub)
+	}
+
+	// empty matches, anchors and word boundaries produce no output
+	return nil
+}
+
+func (g patternGenerator) repeat(builder *strings.Builder, re *syntax.Regexp, min, max int) error {
+	if max < 0 {
+		max = min + patternMaxRepeat
+	}
+	count := min
+	if !g.shortest && max > min {
+		count += g.r.Intn(max - min + 1)
+	}
+	for i := 0; i < count; i++ {
+		if err := g.generate(builder, re); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// classRune returns a random rune of the class ranges, an empty class, e.g. [^\x00-\x{10FFFF}], matches no rune
+func (g patternGenerator) classRune(ranges []rune) (rune, error) {
+	if len(ranges) == 0 {
+		return 0, errPatternNoMatch
+	}
+	if printable := clampRanges(ranges, patternPrintableMin, patternPrintableMax); len(printable) > 0 {
+		ranges = printable
+	}
+
+	total := 0
+	for i := 0; i < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+	n := g.r.Intn(total)
+	for i := 0; i < len(ranges); i += 2 {
+		size := int(ranges[i+1]-ranges[i]) + 1
+		if n < size {
+			return ranges[i] + rune(n), nil
+		}
+		n -= size
+	}
+
+	return ranges[0], nil
+}
+
+func clampRanges(ranges []rune, min, max rune) []rune {
+	var result []rune
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo < min {
+			lo = min
+		}
+		if hi > max {
+			hi = max
+		}
+		if lo <= hi {
+			result = append(result, lo, hi)
+		}
+	}
+
+	return result
+}
+
+func minPatternLength(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpCharClass, syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		return 1
+	case syntax.OpCapture:
+		return minPatternLength(re.Sub[0])
+	case syntax.OpPlus:
+		return minPatternLength(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * minPatternLength(re.Sub[0])
+	case syntax.OpConcat:
+		result := 0
+		for _, sub := range re.Sub {
+			result += minPatternLength(sub)
+		}
+		return result
+	case syntax.OpAlternate:
+		result := -1
+		for _, sub := range re.Sub {
+			if l := minPatternLength(sub); result == -1 || l < result {
+				result = l
+			}
+		}
+		return result
+	}
+
+	return 0
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/pseudomuto/protokit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/kordax/basic-utils/opt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGeneratePattern(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name    string
+		pattern string
+		maxLen  opt.Opt[int]
+		wantErr bool
+	}{
+		{name: "order id", pattern: `^ORD-[0-9]{6}$`},
+		{name: "sku", pattern: `^[A-Z]{3}-\d{2,4}(-[a-z]+)?$`},
+		{name: "locale", pattern: `^[a-z]{2}(_[A-Z]{2})?$`},
+		{name: "alternation", pattern: `^(EUR|USD|GBP)$`},
+		{name: "negated class", pattern: `^[^0-9]+$`},
+		{name: "unbounded with max length", pattern: `^a+b*$`, maxLen: opt.Of(3)},
+		{name: "shortest alternative fits", pattern: `^(abcdefgh|ab)$`, maxLen: opt.Of(2)},
+		{name: "too long", pattern: `^[a-z]{10}$`, maxLen: opt.Of(5), wantErr: true},
+		{name: "invalid", pattern: `^[a-z$`, wantErr: true},
+		{name: "empty class", pattern: `^[^\x00-\x{10FFFF}]$`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				value, err := generatePattern(tt.pattern, tt.maxLen, r)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				assert.Regexp(t, regexp.MustCompile(tt.pattern), value)
+				if tt.maxLen.Present() {
+					assert.LessOrEqual(t, utf8.RuneCountInString(value), *tt.maxLen.Get())
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateString_Pattern(t *testing.T) {
+	flags := FieldFlags{pattern: opt.Of(`^[A-Z]{2}[0-9]{4}$`)}
+	value, err := generateString(nil, flags, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.Regexp(t, `^[A-Z]{2}[0-9]{4}$`, value)
+
+	field := NewMessageField(&protokit.FieldDescriptor{}, &protokit.Descriptor{}, "", ValueTypeString, &flags)
+	schema := newSchemaBuilder(nil, nil).fieldSchema(field)
+	assert.Equal(t, `^[A-Z]{2}[0-9]{4}$`, schema.Pattern)
+}
+
+func TestParseFieldFlags_quotedPattern(t *testing.T) {
+	const email = `^[^@\s]+@[^@\s]+\.[a-z]{2,}$`
+	files := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		// trailing comment of Item.name
+		orders.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{{
+			Path:             []int32{4, 1, 2, 0},
+			Span:             []int32{4, 2, 20},
+			TrailingComments: proto.String(" Customer email @pattern=`" + email + "` @len=64\n"),
+		}}}
+	})
+	field := newApiIndex(files).messages["shop.Item"].fields[0]
+	require.True(t, field.flags.Present())
+
+	flags := field.flags.Get()
+	assert.Equal(t, email, *flags.pattern.Get())
+	assert.Equal(t, 64, *flags.maxLength.Get())
+	assert.Equal(t, []string{"len=64"}, flags.other)
+	assert.Equal(t, "Customer email", field.description)
+
+	value, err := generateString(nil, *flags, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.Regexp(t, email, value)
+}
@@ -0,0 +1,249 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPipelineConfigFile is the configuration file, which is used if it exists in the working directory
+const DefaultPipelineConfigFile = "pbmd.yaml"
+
+// DefaultTemplateName selects the default layout of the template generator instead of a template file
+const DefaultTemplateName = "default"
+
+type OutputFormat string
+
+const (
+	OutputFormatMarkdown OutputFormat = "md"
+	OutputFormatOpenAPI  OutputFormat = "openapi"
+	OutputFormatAsyncAPI OutputFormat = "asyncapi"
+	OutputFormatDot      OutputFormat = "dot"
+)
+
+/*
+PipelineConfig is the configuration of the whole pipeline: the parsed .proto files, filters, render syntax, heading texts,
+autocode options and the documents written from the same parsed files.
+*/
+type PipelineConfig struct {
+	Inputs   InputsConfig   `yaml:"inputs"`
+	Filters  FiltersConfig  `yaml:"filters"`
+	Render   RenderSyntax   `yaml:"render"`
+	Headings Headings       `yaml:"headings"`
+	Autocode AutocodeConfig `yaml:"autocode"`
+	Outputs  []OutputConfig `yaml:"outputs"`
+}
+
+type InputsConfig struct {
+	// Dir is the .proto files directory, it's also the first import path
+	Dir string `yaml:"dir"`
+	// Files force specific files instead of all .proto files of the directory
+	Files       []string `yaml:"files"`
+	ImportPaths []string `yaml:"import_paths"`
+	// TmpDir is the temporary protobuf output directory
+	TmpDir string `yaml:"tmp_dir"`
+}
+
+// FiltersConfig are applied to every output, audience and version of the output override them
+type FiltersConfig struct {
+	Include      []string `yaml:"include"`
+	Exclude      []string `yaml:"exclude"`
+	IncludeNames []string `yaml:"include_names"`
+	ExcludeNames []string `yaml:"exclude_names"`
+	Audience     string   `yaml:"audience"`
+	AsOfVersion  string   `yaml:"as_of_version"`
+}
+
+// RenderSyntax is the markdown syntax of the rendered documents, empty values keep the default syntax
+type RenderSyntax struct {
+	Emphasis  string `yaml:"emphasis"`
+	Header    string `yaml:"header"`
+	Rule      string `yaml:"rule"`
+	Codeblock string `yaml:"codeblock"`
+	List      string `yaml:"list"`
+}
+
+type AutocodeConfig struct {
+	// Seed makes @autocode examples reproducible, 0 means a random seed
+	Seed              int64 `yaml:"seed"`
+	IncludeDeprecated bool  `yaml:"include_deprecated"`
+}
+
+type OutputConfig struct {
+	Path   string       `yaml:"path"`
+	Format OutputFormat `yaml:"format"`
+	// Prefix is the markdown document added to the beginning of the output
+	Prefix string `yaml:"prefix"`
+	// Template is the text/template file, which blocks replace the default layout ones, 'default' is the default layout
+	Template    string    `yaml:"template"`
+	Split       string    `yaml:"split"`
+	Splice      bool      `yaml:"splice"`
+	Diagrams    string    `yaml:"diagrams"`
+	Audience    string    `yaml:"audience"`
+	AsOfVersion string    `yaml:"as_of_version"`
+	TOC         TOCConfig `yaml:"toc"`
+	API         APIConfig `yaml:"api"`
+	Dot         DotConfig `yaml:"dot"`
+}
+
+type TOCConfig struct {
+	HideDeprecated bool `yaml:"hide_deprecated"`
+	SinceBadges    bool `yaml:"since_badges"`
+}
+
+type APIConfig struct {
+	Version   string `yaml:"version"`
+	Channel   string `yaml:"channel"`
+	ServerUrl string `yaml:"server_url"`
+}
+
+type DotConfig struct {
+	Cluster       string `yaml:"cluster"`
+	Root          string `yaml:"root"`
+	Depth         int    `yaml:"depth"`
+	HideWellKnown bool   `yaml:"hide_wkt"`
+}
+
+// LoadPipelineConfig reads the YAML configuration, unknown keys are rejected. The configuration is not validated.
+func LoadPipelineConfig(r io.Reader) (*PipelineConfig, error) {
+	config := &PipelineConfig{}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid configuration: %s", err.Error())
+	}
+
+	return config, nil
+}
+
+// ApplyDefaults sets the default values of the omitted settings
+func (c *PipelineConfig) ApplyDefaults() {
+	if c.Inputs.TmpDir == "" {
+		c.Inputs.TmpDir = "doc-generator-tmp"
+	}
+	for i := range c.Outputs {
+		output := &c.Outputs[i]
+		if output.Format == "" {
+			output.Format = OutputFormatMarkdown
+		}
+		if output.API.Version == "" {
+			output.API.Version = "1.0.0"
+		}
+		if output.API.Channel == "" {
+			output.API.Channel = "/"
+		}
+		if output.Dot.Cluster == "" {
+			output.Dot.Cluster = string(DotClusteringPackage)
+		}
+	}
+}
+
+// Validate returns all errors of the configuration, each one is prefixed with the path of the invalid setting
+func (c *PipelineConfig) Validate() error {
+	var errs []error
+	invalid := func(path string, err error) {
+		errs = append(errs, fmt.Errorf("%s: %s", path, err.Error()))
+	}
+
+	if c.Inputs.Dir == "" {
+		invalid("inputs.dir", errors.New(".proto files directory is required"))
+	}
+	if _, err := Filter(nil, c.Filters.options()); err != nil {
+		invalid("filters", err)
+	}
+	if _, err := c.Render.Config(); err != nil {
+		invalid("render", err)
+	}
+	if len(c.Outputs) == 0 {
+		invalid("outputs", errors.New("at least one output is required"))
+	}
+	for i, output := range c.Outputs {
+		path := fmt.Sprintf("outputs[%d]", i)
+		if output.Path == "" {
+			invalid(path+".path", errors.New("output path is required"))
+		}
+		switch output.Format {
+		case OutputFormatMarkdown, OutputFormatOpenAPI, OutputFormatAsyncAPI, OutputFormatDot:
+		default:
+			invalid(path+".format", fmt.Errorf("unsupported output format: '%s', supported formats are: md, openapi, asyncapi, dot", output.Format))
+		}
+		split, err := ParseSplitMode(output.Split)
+		if err != nil {
+			invalid(path+".split", err)
+		}
+		if split != SplitModeNone && (output.Format != OutputFormatMarkdown || output.Splice) {
+			invalid(path+".split", errors.New("split output is supported for markdown format without splice only"))
+		}
+		if output.Template != "" && (output.Format != OutputFormatMarkdown || split != SplitModeNone || output.Diagrams != "") {
+			invalid(path+".template", errors.New("template is supported for single markdown documents without diagrams only"))
+		}
+		if _, err = ParseDiagramMode(output.Diagrams); err != nil {
+			invalid(path+".diagrams", err)
+		}
+		if _, err = ParseDotClustering(output.Dot.Cluster); err != nil {
+			invalid(path+".dot.cluster", err)
+		}
+		if output.Dot.Depth < 0 {
+			invalid(path+".dot.depth", fmt.Errorf("depth must not be negative: %d", output.Dot.Depth))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// FilterOptions returns the file and name filters of the configuration
+func (c *PipelineConfig) FilterOptions() FilterOptions {
+	return c.Filters.options()
+}
+
+func (c FiltersConfig) options() FilterOptions {
+	return FilterOptions{
+		IncludeFiles: c.Include,
+		ExcludeFiles: c.Exclude,
+		IncludeNames: c.IncludeNames,
+		ExcludeNames: c.ExcludeNames,
+	}
+}
+
+// Config returns the renderer configuration of the syntax
+func (s RenderSyntax) Config() (*Config, error) {
+	config := DefaultRenderConfig()
+	var errs []error
+	parse := func(name, value string, supported []string, apply func(i int)) {
+		if value == "" {
+			return
+		}
+		for i, v := range supported {
+			if v == strings.ToLower(value) {
+				apply(i)
+				return
+			}
+		}
+		errs = append(errs, fmt.Errorf("unsupported %s syntax: '%s', supported values are: %s", name, value, strings.Join(supported, ", ")))
+	}
+
+	parse("emphasis", s.Emphasis, []string{"asterisks", "underscores"}, func(i int) {
+		config.EmphasisSyntax = md.EmphasisSyntax(i)
+	})
+	parse("header", s.Header, []string{"number_signs", "underlined"}, func(i int) {
+		config.HeaderSyntax = md.HeaderSyntax(i)
+	})
+	parse("rule", s.Rule, []string{"asterisks", "underscores", "dashes"}, func(i int) {
+		config.RuleSyntax = md.RuleSyntax(i)
+	})
+	parse("codeblock", s.Codeblock, []string{"backticks", "tildes"}, func(i int) {
+		config.CodeblockSyntax = md.CodeblockSyntax(i)
+	})
+	parse("list", s.List, []string{"asterisk", "dash", "plus"}, func(i int) {
+		config.ListSyntax = md.ListSyntax(i)
+	})
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return config, nil
+}
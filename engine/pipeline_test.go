@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPipelineConfig = `
+inputs:
+  dir: ./protos
+  import_paths: [./third_party]
+filters:
+  exclude: ["**/internal/**"]
+  audience: partner
+render:
+  emphasis: underscores
+  list: dash
+headings:
+  table_of_contents: Contents
+autocode:
+  seed: 42
+outputs:
+  - path: ./docs/api.md
+    toc:
+      since_badges: true
+  - path: ./docs/openapi.yaml
+    format: openapi
+    api:
+      version: 2.0.0
+`
+
+func TestLoadPipelineConfig(t *testing.T) {
+	config, err := LoadPipelineConfig(strings.NewReader(testPipelineConfig))
+	require.NoError(t, err)
+	config.ApplyDefaults()
+	require.NoError(t, config.Validate())
+
+	assert.Equal(t, "./protos", config.Inputs.Dir)
+	assert.Equal(t, []string{"./third_party"}, config.Inputs.ImportPaths)
+	assert.Equal(t, "doc-generator-tmp", config.Inputs.TmpDir)
+	assert.Equal(t, FilterOptions{ExcludeFiles: []string{"**/internal/**"}}, config.FilterOptions())
+	assert.Equal(t, int64(42), config.Autocode.Seed)
+	require.Len(t, config.Outputs, 2)
+	assert.Equal(t, OutputFormatMarkdown, config.Outputs[0].Format)
+	assert.True(t, config.Outputs[0].TOC.SinceBadges)
+	assert.Equal(t, "1.0.0", config.Outputs[0].API.Version)
+	assert.Equal(t, OutputFormatOpenAPI, config.Outputs[1].Format)
+	assert.Equal(t, "2.0.0", config.Outputs[1].API.Version)
+	assert.Equal(t, string(DotClusteringPackage), config.Outputs[1].Dot.Cluster)
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := LoadPipelineConfig(strings.NewReader("outputs:\n  - path: api.md\n    fromat: md\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field fromat not found")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		config, err := LoadPipelineConfig(strings.NewReader(""))
+		require.NoError(t, err)
+		assert.Empty(t, config.Outputs)
+	})
+}
+
+func TestPipelineConfig_Validate(t *testing.T) {
+	config := &PipelineConfig{
+		Filters: FiltersConfig{IncludeNames: []string{"shop.(Item"}},
+		Render:  RenderSyntax{Header: "hashes"},
+		Outputs: []OutputConfig{
+			{Path: "api.md", Split: "file", Splice: true, Template: "house.tmpl"},
+			{Format: "html", Dot: DotConfig{Depth: -1}},
+		},
+	}
+	config.ApplyDefaults()
+	err := config.Validate()
+	require.Error(t, err)
+
+	messages := strings.Split(err.Error(), "\n")
+	assert.Equal(t, []string{
+		"inputs.dir: .proto files directory is required",
+		"filters: invalid include pattern 'shop.(Item': error parsing regexp: missing closing ): `^(?:shop.(Item)$`",
+		"render: unsupported header syntax: 'hashes', supported values are: number_signs, underlined",
+		"outputs[0].split: split output is supported for markdown format without splice only",
+		"outputs[0].template: template is supported for single markdown documents without diagrams only",
+		"outputs[1].path: output path is required",
+		"outputs[1].format: unsupported output format: 'html', supported formats are: md, openapi, asyncapi, dot",
+		"outputs[1].dot.depth: depth must not be negative: -1",
+	}, messages)
+}
+
+func TestRenderSyntax_Config(t *testing.T) {
+	config, err := RenderSyntax{}.Config()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRenderConfig(), config)
+
+	config, err = RenderSyntax{Emphasis: "underscores", Header: "underlined", Rule: "dashes", Codeblock: "tildes", List: "Plus"}.Config()
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		EmphasisSyntax:  md.EmphasisSyntaxUnderscores,
+		HeaderSyntax:    md.HeaderSyntaxUnderlined,
+		RuleSyntax:      md.RuleSyntaxDashes,
+		CodeblockSyntax: md.CodeblockSyntaxTildas,
+		ListSyntax:      md.ListSyntaxPlus,
+	}, config)
+
+	_, err = RenderSyntax{List: "star"}.Config()
+	assert.EqualError(t, err, "unsupported list syntax: 'star', supported values are: asterisk, dash, plus")
+}
+
+func TestMDGenerator_headings(t *testing.T) {
+	generator := NewMDGenerator(NewSeededCodegenerator(1))
+	generator.SetHeadings(Headings{TableOfContents: "Contents", Enums: "Enumerations"})
+	document, err := generator.Generate(diffTestFiles(t, nil))
+	require.NoError(t, err)
+
+	var builder strings.Builder
+	require.NoError(t, NewMarkdownRenderer(DefaultRenderConfig()).Render(&builder, document))
+	content := builder.String()
+	assert.Contains(t, content, "Contents")
+	assert.Contains(t, content, "## Enumerations")
+	assert.Contains(t, content, "## API Description")
+	assert.NotContains(t, content, "Table Of Contents")
+}
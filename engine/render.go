@@ -1,15 +1,15 @@
 package engine
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"sort"
 	"strconv"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/kordax/pb-md5-generator/engine/md"
-	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
-	mathutils "gitlab.com/kordax/basic-utils/math-utils"
 )
 
 type Config struct {
@@ -30,13 +30,25 @@ func DefaultRenderConfig() *Config {
 	}
 }
 
+/*
+Renderer streams the document into the writer. Implementations are expected to be stateless and safe for concurrent use.
+*/
 type Renderer interface {
-	Render(doc *md.Document) (string, error)
+	Render(w io.Writer, doc *md.Document) error
+}
+
+// RenderString renders the document into a string, for documents small enough to be held in memory
+func RenderString(renderer Renderer, doc *md.Document) (string, error) {
+	var builder strings.Builder
+	if err := renderer.Render(&builder, doc); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
 }
 
 type MarkdownRenderer struct {
-	builder strings.Builder
-	config  Config
+	config Config
 }
 
 func NewMarkdownRenderer(config *Config) *MarkdownRenderer {
@@ -45,24 +57,45 @@ func NewMarkdownRenderer(config *Config) *MarkdownRenderer {
 	}
 }
 
-func (g *MarkdownRenderer) Render(doc *md.Document) (string, error) {
-	sections := doc.GetSections()
-	sort.Slice(sections, func(i, j int) bool {
+func (r *MarkdownRenderer) Render(w io.Writer, doc *md.Document) error {
+	buffer := bufio.NewWriter(w)
+	g := newMarkdownWriter(buffer, r.config, false)
+	// sections and elements are sorted in copies, so the document is never modified by the renderer
+	sections := append([]md.Section(nil), doc.GetSections()...)
+	sort.SliceStable(sections, func(i, j int) bool {
 		return sections[i].GetIndex() < sections[j].GetIndex()
 	})
 	for _, section := range sections {
 		if err := g.renderSection(section); err != nil {
-			return "", err
+			return err
 		}
 	}
 
-	return g.builder.String(), nil
+	// the first write error is kept by the buffer and returned on flush
+	return buffer.Flush()
+}
+
+type stringWriter interface {
+	io.StringWriter
+	io.ByteWriter
+}
+
+// markdownWriter holds the state of a single Render call
+type markdownWriter struct {
+	w      stringWriter
+	config Config
+	// table is set when rendering table cells, which require additional escaping
+	table bool
 }
 
-func (g *MarkdownRenderer) renderSection(section ...md.Section) error {
+func newMarkdownWriter(w stringWriter, config Config, table bool) *markdownWriter {
+	return &markdownWriter{w: w, config: config, table: table}
+}
+
+func (g *markdownWriter) renderSection(section ...md.Section) error {
 	for _, s := range section {
-		elements := s.GetElements()
-		sort.Slice(elements, func(i, j int) bool {
+		elements := append([]md.Element(nil), s.GetElements()...)
+		sort.SliceStable(elements, func(i, j int) bool {
 			return elements[i].GetIndex() < elements[j].GetIndex()
 		})
 		for i, e := range elements {
@@ -125,7 +158,7 @@ func (g *MarkdownRenderer) renderSection(section ...md.Section) error {
 }
 
 // parent should always be a dereferenced pointer
-func (g *MarkdownRenderer) renderElement(element md.Element) (error, int) {
+func (g *markdownWriter) renderElement(element md.Element) (error, int) {
 	if element == nil {
 		return nil, 0
 	}
@@ -154,15 +187,18 @@ func (g *MarkdownRenderer) renderElement(element md.Element) (error, int) {
 		return g.renderTable(element.(*md.Table)), 0
 	case md.ElementTypeHtmlRef:
 		return g.renderHtmlRef(element.(*md.HtmlRef))
+	case md.ElementTypeRaw:
+		return g.renderRaw(element.(*md.Raw))
 	}
 
 	return fmt.Errorf("unsupported element type received"), 0
 }
 
-func (g *MarkdownRenderer) renderHeader(header ...*md.Header) (error, int) {
+func (g *markdownWriter) renderHeader(header ...*md.Header) (error, int) {
 	chars := 0
 
 	for _, h := range header {
+		text := escapeInline(h.GetText(), false)
 		switch g.config.HeaderSyntax {
 		case md.HeaderSyntaxNumberSigns:
 			n := 1
@@ -180,18 +216,18 @@ func (g *MarkdownRenderer) renderHeader(header ...*md.Header) (error, int) {
 			case md.HeaderLevelSix:
 				n = 6
 			}
-			g.builder.WriteString(strings.Repeat(md.HeaderDelimiterBasic, n) + " ")
+			g.w.WriteString(strings.Repeat(md.HeaderDelimiterBasic, n) + " ")
 			chars += n + 1
-			chars += g.renderString(h.GetText())
+			chars += g.renderString(text)
 		default:
 			del, _ := getHeaderDelimiter(g.config, h)
-			chars += g.renderString(h.GetText())
-			if h.GetText() != "" {
-				textChars := utf8.RuneCountInString(h.GetText())
-				g.builder.WriteString("\n" + strings.Repeat(del, textChars))
+			chars += g.renderString(text)
+			if text != "" {
+				textChars := utf8.RuneCountInString(text)
+				g.w.WriteString("\n" + strings.Repeat(del, textChars))
 				chars += textChars
 			} else {
-				g.builder.WriteString("\n" + strings.Repeat(del, 3))
+				g.w.WriteString("\n" + strings.Repeat(del, 3))
 				chars += utf8.RuneCountInString(del) * 3
 			}
 		}
@@ -202,7 +238,7 @@ func (g *MarkdownRenderer) renderHeader(header ...*md.Header) (error, int) {
 	return nil, chars
 }
 
-func (g *MarkdownRenderer) renderParagraph(paragraph ...*md.Paragraph) (error, int) {
+func (g *markdownWriter) renderParagraph(paragraph ...*md.Paragraph) (error, int) {
 	chars := 0
 	for _, p := range paragraph {
 		for _, e := range p.GetElements() {
@@ -218,7 +254,7 @@ func (g *MarkdownRenderer) renderParagraph(paragraph ...*md.Paragraph) (error, i
 	return nil, chars
 }
 
-func (g *MarkdownRenderer) renderBlockquote(blockquote ...*md.Blockquote) (error, int) {
+func (g *markdownWriter) renderBlockquote(blockquote ...*md.Blockquote) (error, int) {
 	chars := 0
 	for _, q := range blockquote {
 		for _, e := range q.GetElements() {
@@ -227,12 +263,12 @@ func (g *MarkdownRenderer) renderBlockquote(blockquote ...*md.Blockquote) (error
 				c := (e).(*md.Paragraph)
 				if len(c.GetElements()) > 0 {
 					chars += 2
-					g.builder.WriteString("> ")
+					g.w.WriteString("> ")
 					g.newline()
 					nonEmptyParagraph = true
 				}
 			}
-			g.builder.WriteString("> ")
+			g.w.WriteString("> ")
 			chars += 2
 			err, written := g.renderElement(e)
 			if err != nil {
@@ -241,7 +277,7 @@ func (g *MarkdownRenderer) renderBlockquote(blockquote ...*md.Blockquote) (error
 			chars += written
 
 			if nonEmptyParagraph {
-				g.builder.WriteString("> ")
+				g.w.WriteString("> ")
 				g.newline()
 				chars += 2
 			}
@@ -261,7 +297,7 @@ func (g *MarkdownRenderer) renderBlockquote(blockquote ...*md.Blockquote) (error
 	return nil, chars
 }
 
-func (g *MarkdownRenderer) renderList(list ...*md.List) (error, int) {
+func (g *markdownWriter) renderList(list ...*md.List) (error, int) {
 	chars := 0
 	for _, l := range list {
 		for _, e := range l.GetEntries() {
@@ -272,12 +308,12 @@ func (g *MarkdownRenderer) renderList(list ...*md.List) (error, int) {
 	return nil, chars
 }
 
-func (g *MarkdownRenderer) renderListEntry(entry *md.ListEntry, ordered bool, level int) int {
+func (g *markdownWriter) renderListEntry(entry *md.ListEntry, ordered bool, level int) int {
 	chars := 0
 	if level > 0 {
 		tab := strings.Repeat(" ", level*5)
 		chars += utf8.RuneCountInString(tab)
-		g.builder.WriteString(tab)
+		g.w.WriteString(tab)
 	}
 
 	var del string
@@ -288,7 +324,7 @@ func (g *MarkdownRenderer) renderListEntry(entry *md.ListEntry, ordered bool, le
 		del += " "
 	}
 	chars += utf8.RuneCountInString(del)
-	g.builder.WriteString(del)
+	g.w.WriteString(del)
 	err, written := g.renderElement(entry.GetElement())
 	if err != nil {
 		return 0
@@ -306,23 +342,30 @@ func (g *MarkdownRenderer) renderListEntry(entry *md.ListEntry, ordered bool, le
 	return chars
 }
 
-func (g *MarkdownRenderer) renderCodeblock(codeblock ...*md.Codeblock) (error, int) {
+func (g *markdownWriter) renderCodeblock(codeblock ...*md.Codeblock) (error, int) {
 	chars := 0
-	del, delChars := getCodeblockDelimiter(g.config)
 
 	for _, b := range codeblock {
-		g.builder.WriteString(del)
-		g.newline()
-		chars += delChars
-		if b.GetText() != "" {
-			chars += g.renderString(b.GetText())
-		} else {
+		if b.GetText() == "" {
 			return fmt.Errorf("empty code blocks are not supported"), 0
 		}
+		if b.GetTitle() != "" {
+			title := escapeInline(b.GetTitle(), g.table)
+			del, delChars := getEmphasisItalicDelimiter(g.config)
+			g.w.WriteString(del + title + del)
+			chars += utf8.RuneCountInString(title) + delChars*2
+			g.newline()
+			g.newline()
+		}
+		del, delChars := getCodeblockFence(g.config, b.GetText())
+		g.w.WriteString(del + b.GetLanguage())
+		g.newline()
+		chars += delChars + utf8.RuneCountInString(b.GetLanguage())
+		chars += g.renderString(b.GetText())
 		if !strings.HasSuffix(b.GetText(), "\n") {
 			g.newline()
 		}
-		g.builder.WriteString(del)
+		g.w.WriteString(del)
 		chars += delChars
 		g.newline()
 	}
@@ -330,22 +373,22 @@ func (g *MarkdownRenderer) renderCodeblock(codeblock ...*md.Codeblock) (error, i
 	return nil, chars
 }
 
-func (g *MarkdownRenderer) renderImage(image ...*md.Image) (error, int) {
+func (g *markdownWriter) renderImage(image ...*md.Image) (error, int) {
 	chars := 0
 	for _, l := range image {
-		imgStr := fmt.Sprintf("![%s](%s \"%s\")", l.GetText(), l.GetUrl(), l.GetTitle())
+		imgStr := fmt.Sprintf("![%s](%s \"%s\")", escapeInline(l.GetText(), g.table), escapeUrl(l.GetUrl(), g.table), escapeLinkTitle(l.GetTitle()))
 		chars += utf8.RuneCountInString(imgStr)
-		g.builder.WriteString(imgStr)
+		g.w.WriteString(imgStr)
 	}
 
 	return nil, chars
 }
 
-func (g *MarkdownRenderer) renderRule(rule ...*md.Rule) (error, int) {
+func (g *markdownWriter) renderRule(rule ...*md.Rule) (error, int) {
 	chars := 0
 	del, delChars := getRuleDelimiter(g.config)
 	for range rule {
-		g.builder.WriteString(del)
+		g.w.WriteString(del)
 		chars += delChars
 		g.newline()
 	}
@@ -353,18 +396,18 @@ func (g *MarkdownRenderer) renderRule(rule ...*md.Rule) (error, int) {
 	return nil, chars
 }
 
-func (g *MarkdownRenderer) renderLink(link ...*md.Link) (error, int) {
+func (g *markdownWriter) renderLink(link ...*md.Link) (error, int) {
 	chars := 0
 	for _, l := range link {
-		urlStr := fmt.Sprintf("[%s](%s)", l.GetText(), l.GetUrl())
+		urlStr := fmt.Sprintf("[%s](%s)", escapeInline(l.GetText(), g.table), escapeUrl(l.GetUrl(), g.table))
 		chars += utf8.RuneCountInString(urlStr)
-		g.builder.WriteString(urlStr)
+		g.w.WriteString(urlStr)
 	}
 
 	return nil, chars
 }
 
-func (g *MarkdownRenderer) renderTable(table ...*md.Table) error {
+func (g *markdownWriter) renderTable(table ...*md.Table) error {
 	for _, t := range table {
 		if err := g.renderColumns(t.GetRows(), t.GetColumns()...); err != nil {
 			return err
@@ -375,75 +418,64 @@ func (g *MarkdownRenderer) renderTable(table ...*md.Table) error {
 	return nil
 }
 
-func (g *MarkdownRenderer) renderColumns(tableRows int, columns ...md.Column) error {
-	columnMaxLengths := map[int]int{}
-	written := 0
-
-	// render column headers
-	for _, column := range columns {
-		if column.GetIndex() == 0 {
-			g.builder.WriteByte('|')
-			written++
-		}
-		g.builder.WriteString(" " + column.GetName())
-		written += utf8.RuneCountInString(column.GetName()) + 1
-		maxLength := mathutils.MaxInt(arrayutils.Map(column.GetRows(), func(v *md.Row) int {
-			return mathutils.SumInt(arrayutils.Map(v.GetElements(), func(e *md.Element) int {
-				switch (*e).GetType() {
-				case md.ElementTypeText:
-					c := (*e).(*md.Text)
-					_, dellen := getEmphasisDelimiter(g.config, c.GetEmphasis())
-					return c.GetLen() + dellen*2
-				case md.ElementTypeLink:
-					c := (*e).(*md.Link)
-					return utf8.RuneCountInString(c.GetUrl()) + utf8.RuneCountInString(c.GetText()) + 4
-				case md.ElementTypeCodeblock:
-					c := (*e).(*md.Codeblock)
-					del, dellen := getCodeblockDelimiter(g.config)
-					return utf8.RuneCountInString(c.GetText()) + strings.Count(c.GetText(), del)*dellen
-				default:
-					return 0
+func (g *markdownWriter) renderColumns(tableRows int, columns ...md.Column) error {
+	// cells are rendered before the table, since column width and aligned content padding depend on them
+	type cell struct {
+		content string
+		written int
+	}
+	cells := make([][]cell, len(columns))
+	columnMaxLengths := make([]int, len(columns))
+	for c, column := range columns {
+		rows := column.GetRows()
+		cells[c] = make([]cell, tableRows)
+		for r := 0; r < tableRows && r < len(rows); r++ {
+			var content strings.Builder
+			renderer := newMarkdownWriter(&content, g.config, true)
+			for _, element := range rows[r].GetElements() {
+				err, w := renderer.renderElement(element)
+				if err != nil {
+					return err
 				}
-			}))
-		}))
-		nameLen := utf8.RuneCountInString(column.GetName())
-		if maxLength < nameLen {
-			maxLength = nameLen
+				cells[c][r].written += w
+			}
+			cells[c][r].content = content.String()
+			if cells[c][r].written > columnMaxLengths[c] {
+				columnMaxLengths[c] = cells[c][r].written
+			}
 		}
-		if maxLength < 3 {
-			maxLength = 3
+		if nameLen := utf8.RuneCountInString(escapeInline(column.GetName(), true)); columnMaxLengths[c] < nameLen {
+			columnMaxLengths[c] = nameLen
 		}
-		columnMaxLengths[column.GetIndex()-1] = maxLength
-		toFill := maxLength - utf8.RuneCountInString(column.GetName())
-		if toFill > 0 {
-			g.builder.WriteString(strings.Repeat(" ", toFill))
-			g.builder.WriteString(" |")
-			written += toFill + 2
-		} else {
-			g.builder.WriteString(" |")
-			written++
+		if columnMaxLengths[c] < 3 {
+			columnMaxLengths[c] = 3
+		}
+		// separator is wrapped with spaces in the cell, so the alignment delimiter may take them
+		if delLen := utf8.RuneCountInString(getColumnAlignmentDelimiter(column.GetAlignment())) - 2; columnMaxLengths[c] < delLen {
+			columnMaxLengths[c] = delLen
 		}
 	}
 
+	// render column headers
+	for c, column := range columns {
+		if c == 0 {
+			g.w.WriteByte('|')
+		}
+		name := escapeInline(column.GetName(), true)
+		g.renderCell(name, utf8.RuneCountInString(name), columnMaxLengths[c], column.GetAlignment())
+	}
+
 	if len(columns) > 0 {
 		g.newline()
 	}
 
 	// render header underline
-	for c := 0; c < len(columns); c++ {
+	for c, column := range columns {
 		if c == 0 {
-			g.builder.WriteByte('|')
-		}
-		maxLength := columnMaxLengths[c-1]
-		toFill := maxLength + 2
-		if toFill > 0 {
-			g.builder.WriteString(strings.Repeat("-", toFill))
-			g.builder.WriteString("|")
-			written += toFill + 3
-		} else {
-			g.builder.WriteString("|")
-			written += 2
+			g.w.WriteByte('|')
 		}
+		g.w.WriteString(columnSeparator(column.GetAlignment(), columnMaxLengths[c]+2))
+		g.w.WriteString("|")
 
 		if c == len(columns)-1 {
 			g.newline()
@@ -452,33 +484,11 @@ func (g *MarkdownRenderer) renderColumns(tableRows int, columns ...md.Column) er
 
 	// render rows sequentially where 'r' is row number and c is a column
 	for r := 0; r < tableRows; r++ {
-		for c := 0; c < len(columns); c++ {
-			column := columns[c]
-			rows := column.GetRows()
-			if column.GetIndex() == 0 {
-				g.builder.WriteByte('|')
-			}
-			rowWritten := 0
-			g.builder.WriteByte(' ')
-			if r <= len(rows)-1 {
-				row := rows[r]
-				for _, element := range row.GetElements() {
-					err, w := g.renderElement(element)
-					if err != nil {
-						return err
-					}
-					written += w
-					rowWritten += w
-				}
-			}
-			maxLength := columnMaxLengths[column.GetIndex()-1]
-			toFill := maxLength - rowWritten + 1
-			if toFill > 0 {
-				g.builder.WriteString(strings.Repeat(" ", toFill))
-				written += toFill
+		for c, column := range columns {
+			if c == 0 {
+				g.w.WriteByte('|')
 			}
-			g.builder.WriteString("|")
-			written++
+			g.renderCell(cells[c][r].content, cells[c][r].written, columnMaxLengths[c], column.GetAlignment())
 
 			if c == len(columns)-1 && r != tableRows-1 {
 				g.newline()
@@ -489,61 +499,114 @@ func (g *MarkdownRenderer) renderColumns(tableRows int, columns ...md.Column) er
 	return nil
 }
 
-func (g *MarkdownRenderer) renderText(text ...*md.Text) int {
+/*
+renderCell writes cell content of 'written' width padded to the column width according to the alignment.
+Cells without alignment are padded as left aligned ones.
+*/
+func (g *markdownWriter) renderCell(content string, written, width int, alignment md.ColumnAlignment) {
+	toFill := width - written
+	if toFill < 0 {
+		toFill = 0
+	}
+	left := 0
+	switch alignment {
+	case md.ColumnAlignmentRight:
+		left = toFill
+	case md.ColumnAlignmentCenter:
+		left = toFill / 2
+	}
+
+	g.w.WriteByte(' ')
+	g.w.WriteString(strings.Repeat(" ", left))
+	g.w.WriteString(content)
+	g.w.WriteString(strings.Repeat(" ", toFill-left))
+	g.w.WriteString(" |")
+}
+
+func (g *markdownWriter) renderText(text ...*md.Text) int {
 	chars := 0
 	for _, t := range text {
 		str := t.GetText()
 		switch t.GetEmphasis() {
 		case md.TextEmphasisNormal:
-			g.builder.WriteString(str)
+			str = escapeInline(str, g.table)
+			g.w.WriteString(str)
 			chars += utf8.RuneCountInString(str)
 		case md.TextEmphasisBold:
 			del, delChars := getEmphasisBoldDelimiter(g.config)
-			g.builder.WriteString(del)
+			g.w.WriteString(del)
 			for str[len(str)-1] == '\n' || str[len(str)-1] == ' ' {
 				str = str[:len(str)-1]
 			}
-			g.builder.WriteString(str)
-			g.builder.WriteString(del + " ")
+			str = escapeInline(str, g.table)
+			g.w.WriteString(str)
+			g.w.WriteString(del + " ")
 			chars += utf8.RuneCountInString(str) + delChars*2 + 1
 		case md.TextEmphasisItalic:
 			del, delChars := getEmphasisItalicDelimiter(g.config)
-			g.builder.WriteString(del)
-			g.builder.WriteString(str)
-			g.builder.WriteString(del + " ")
+			str = escapeInline(str, g.table)
+			g.w.WriteString(del)
+			g.w.WriteString(str)
+			g.w.WriteString(del + " ")
 			chars += utf8.RuneCountInString(str) + delChars*2 + 1
 		case md.TextEmphasisBoldItalic:
 			del, delChars := getEmphasisBoldItalicDelimiter(g.config)
-			g.builder.WriteString(del)
-			g.builder.WriteString(str)
-			g.builder.WriteString(del + " ")
+			str = escapeInline(str, g.table)
+			g.w.WriteString(del)
+			g.w.WriteString(str)
+			g.w.WriteString(del + " ")
 			chars += utf8.RuneCountInString(str) + delChars*2 + 1
+		case md.TextEmphasisStrikethrough:
+			del, delChars := md.EmphasisStrikethroughDelimiter, utf8.RuneCountInString(md.EmphasisStrikethroughDelimiter)
+			str = escapeInline(str, g.table)
+			g.w.WriteString(del)
+			g.w.WriteString(str)
+			g.w.WriteString(del + " ")
+			chars += utf8.RuneCountInString(str) + delChars*2 + 1
+		case md.TextEmphasisCode:
+			str = escapeCodeSpan(str, g.table)
+			g.w.WriteString(str + " ")
+			chars += utf8.RuneCountInString(str) + 1
 		}
 	}
 
 	return chars
 }
 
-func (g *MarkdownRenderer) renderString(text string) int {
-	g.builder.WriteString(text)
+func (g *markdownWriter) renderString(text string) int {
+	g.w.WriteString(text)
 	return utf8.RuneCountInString(text)
 }
 
-func (g *MarkdownRenderer) renderHtmlRef(ref ...*md.HtmlRef) (error, int) {
+func (g *markdownWriter) renderHtmlRef(ref ...*md.HtmlRef) (error, int) {
 	g.newline()
 	chars := 0
 	for _, r := range ref {
-		refStr := fmt.Sprintf("<a name=\"%s\"></a>", r.GetName())
+		refStr := fmt.Sprintf("<a name=\"%s\"></a>", escapeAnchor(r.GetName()))
 		chars += utf8.RuneCountInString(refStr)
-		g.builder.WriteString(refStr)
+		g.w.WriteString(refStr)
 	}
 	g.newline()
 
 	return nil, chars
 }
 
-func (g *MarkdownRenderer) newline() {
-	g.builder.WriteByte('\n')
+// renderRaw writes the source as is, it's terminated with a line break, so the following elements start on a new line
+func (g *markdownWriter) renderRaw(raw ...*md.Raw) (error, int) {
+	chars := 0
+	for _, r := range raw {
+		text := r.GetText()
+		chars += g.renderString(text)
+		if !strings.HasSuffix(text, "\n") {
+			g.newline()
+		}
+	}
+
+	return nil, chars
+}
+
+func (g *markdownWriter) newline() {
+	g.w.WriteByte('\n')
 }
 
 func getHeaderDelimiter(config Config, h *md.Header) (string, int) {
@@ -580,6 +643,32 @@ func getCodeblockDelimiter(config Config) (string, int) {
 	}
 }
 
+/*
+getCodeblockFence returns the configured fence, which is made longer than any run of its characters in the code,
+so the code never closes the block.
+*/
+func getCodeblockFence(config Config, code string) (string, int) {
+	del, delChars := getCodeblockDelimiter(config)
+	fenceChar, _ := utf8.DecodeRuneInString(del)
+	longest, run := 0, 0
+	for _, ch := range code {
+		if ch == fenceChar {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if longest >= delChars {
+		delChars = longest + 1
+		del = strings.Repeat(string(fenceChar), delChars)
+	}
+
+	return del, delChars
+}
+
 func getEmphasisBoldDelimiter(config Config) (string, int) {
 	switch config.EmphasisSyntax {
 	case md.EmphasisSyntaxUnderscores:
@@ -592,7 +681,7 @@ func getEmphasisBoldDelimiter(config Config) (string, int) {
 func getEmphasisItalicDelimiter(config Config) (string, int) {
 	switch config.EmphasisSyntax {
 	case md.EmphasisSyntaxUnderscores:
-		return md.EmphasisBoldUnderscoresDelimiter, utf8.RuneCountInString(md.EmphasisBoldUnderscoresDelimiter)
+		return md.EmphasisItalicUnderscoreDelimiter, utf8.RuneCountInString(md.EmphasisItalicUnderscoreDelimiter)
 	default:
 		return md.EmphasisItalicAsteriskDelimiter, utf8.RuneCountInString(md.EmphasisItalicAsteriskDelimiter)
 	}
@@ -618,15 +707,26 @@ func getUnorderedListDelimiter(config Config) (string, int) {
 	}
 }
 
-func getEmphasisDelimiter(config Config, emphasis md.TextEmphasis) (string, int) {
-	switch emphasis {
-	case md.TextEmphasisItalic:
-		return getEmphasisItalicDelimiter(config)
-	case md.TextEmphasisBold:
-		return getEmphasisBoldDelimiter(config)
-	case md.TextEmphasisBoldItalic:
-		return getEmphasisBoldItalicDelimiter(config)
+func getColumnAlignmentDelimiter(alignment md.ColumnAlignment) string {
+	switch alignment {
+	case md.ColumnAlignmentLeft:
+		return md.ColumnAlignmentLeftDelimiter
+	case md.ColumnAlignmentCenter:
+		return md.ColumnAlignmentCenterDelimiter
+	case md.ColumnAlignmentRight:
+		return md.ColumnAlignmentRightDelimiter
 	default:
-		return "", 0
+		return md.ColumnAlignmentDefaultDelimiter
 	}
 }
+
+// columnSeparator stretches the alignment delimiter to the width by repeating its dashes.
+func columnSeparator(alignment md.ColumnAlignment, width int) string {
+	del := getColumnAlignmentDelimiter(alignment)
+	dashes := width - utf8.RuneCountInString(del)
+	if dashes < 0 {
+		dashes = 0
+	}
+
+	return strings.Replace(del, "-", strings.Repeat("-", dashes+1), 1)
+}
@@ -2,8 +2,10 @@ package engine
 
 import (
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/kordax/pb-md5-generator/engine/md"
@@ -11,10 +13,25 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// testWriter renders single elements into a string
+type testWriter struct {
+	*markdownWriter
+	out *strings.Builder
+}
+
+func newTestWriter(config Config) *testWriter {
+	out := &strings.Builder{}
+	return &testWriter{markdownWriter: newMarkdownWriter(out, config, false), out: out}
+}
+
+func (w *testWriter) String() string {
+	return w.out.String()
+}
+
 func TestNewConfigurableMDGenerator_renderHeader(t *testing.T) {
 	logger := log.With().Str("test", "TestNewConfigurableMDGenerator_renderHeader").Logger()
 	t.Run("level five, basic syntax", func(t *testing.T) {
-		generator := NewMarkdownRenderer(&Config{})
+		generator := newTestWriter(Config{})
 		header := &md.Header{}
 		header.SetLevel(md.HeaderLevelFive)
 		headerText := "test header #5 of mine"
@@ -22,14 +39,14 @@ func TestNewConfigurableMDGenerator_renderHeader(t *testing.T) {
 
 		err, _ := generator.renderHeader(header)
 		assert.NoError(t, err)
-		result := generator.builder.String()
+		result := generator.String()
 		assert.NotEmpty(t, result)
 		assert.Equal(t, "##### "+headerText+"\n", result)
 		logger.Info().Msg("result:")
 		logger.Info().Msg(result)
 	})
 	t.Run("level one, underlined syntax", func(t *testing.T) {
-		generator := NewMarkdownRenderer(&Config{HeaderSyntax: md.HeaderSyntaxUnderlined})
+		generator := newTestWriter(Config{HeaderSyntax: md.HeaderSyntaxUnderlined})
 		header := &md.Header{}
 		header.SetLevel(md.HeaderLevelOne)
 		headerText := "test header #1 of mine"
@@ -37,14 +54,14 @@ func TestNewConfigurableMDGenerator_renderHeader(t *testing.T) {
 
 		err, _ := generator.renderHeader(header)
 		assert.NoError(t, err)
-		result := generator.builder.String()
+		result := generator.String()
 		assert.NotEmpty(t, result)
 		assert.Equal(t, headerText+"\n"+strings.Repeat(md.HeaderDelimiterEquals, len(headerText))+"\n", result)
 		logger.Info().Msg("result:")
 		logger.Info().Msg(result)
 	})
 	t.Run("level two, underlined syntax", func(t *testing.T) {
-		generator := NewMarkdownRenderer(&Config{HeaderSyntax: md.HeaderSyntaxUnderlined})
+		generator := newTestWriter(Config{HeaderSyntax: md.HeaderSyntaxUnderlined})
 		header := &md.Header{}
 		header.SetLevel(md.HeaderLevelTwo)
 		headerText := "test header #2 of mine, length of 42 chars"
@@ -53,7 +70,7 @@ func TestNewConfigurableMDGenerator_renderHeader(t *testing.T) {
 
 		err, _ := generator.renderHeader(header)
 		assert.NoError(t, err)
-		result := generator.builder.String()
+		result := generator.String()
 		assert.NotEmpty(t, result)
 		assert.Equal(t, headerText+"\n"+strings.Repeat(md.HeaderDelimiterDashes, len(headerText))+"\n", result)
 		logger.Info().Msg("result:")
@@ -95,7 +112,7 @@ func TestNewConfigurableMDGenerator_renderTable(t *testing.T) {
 		*section,
 	).Build()
 
-	result, err := generator.Render(&document)
+	result, err := RenderString(generator, &document)
 	assert.NoError(t, err)
 	expected :=
 		`| Column n1        | Column n2        | Column n3        |
@@ -171,7 +188,7 @@ Normal text.
 		*section,
 	).Build()
 
-	result, err := generator.Render(document)
+	result, err := RenderString(generator, document)
 	assert.NoError(t, err)
 	expected := `# Document main section, header_level n1
 
@@ -286,7 +303,7 @@ func TestNewConfigurableMDGenerator_renderLists(t *testing.T) {
 		*section,
 	).Build()
 
-	result, err := generator.Render(document)
+	result, err := RenderString(generator, document)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, result)
 
@@ -339,7 +356,7 @@ func TestNewConfigurableMDGenerator_renderBlockquote(t *testing.T) {
 		*section,
 	).Build()
 
-	result, err := generator.Render(document)
+	result, err := RenderString(generator, document)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, result)
 
@@ -367,3 +384,357 @@ func mkTestTable(prefix string, columns, rows int) *md.Table {
 func mkTestHeader(text string, level md.HeaderLevel) *md.Header {
 	return md.NewHeaderBuilder().Text(text + ", header_level n" + strconv.Itoa(int(level))).Level(level).Build()
 }
+
+func TestNewConfigurableMDGenerator_renderTableAlignment(t *testing.T) {
+	column := func(index int, name string, alignment md.ColumnAlignment, value string) md.Column {
+		return *md.NewColumnBuilder().
+			Index(index).
+			Name(name).
+			Alignment(alignment).
+			Rows(*md.NewRowBuilder().
+				Elements(md.NewTextBuilder().Text(value).Build()).
+				Build(),
+			).Build()
+	}
+	table := md.NewTableBuilder().Rows(1).Columns(
+		column(0, "Default", md.ColumnAlignmentDefault, "a"),
+		column(1, "Left", md.ColumnAlignmentLeft, "b"),
+		column(2, "Center", md.ColumnAlignmentCenter, "c"),
+		column(3, "Right", md.ColumnAlignmentRight, "100"),
+		column(4, "C", md.ColumnAlignmentCenter, "d"),
+	).Build()
+
+	section := md.NewSectionBuilder().Build()
+	section.AddElement(table)
+	document := *md.NewDocumentBuilder().Sections(*section).Build()
+
+	result, err := RenderString(NewMarkdownRenderer(&Config{}), &document)
+	assert.NoError(t, err)
+	expected :=
+		`| Default | Left | Center | Right |  C   |
+|---------|:-----|:------:|------:|:----:|
+| a       | b    |   c    |   100 |  d   |
+`
+	assert.Equal(t, expected, result)
+}
+
+func TestMarkdownRenderer_escaping(t *testing.T) {
+	row := func(elements ...md.Element) md.Row {
+		return *md.NewRowBuilder().Elements(elements...).Build()
+	}
+	list := MkList(false, nil)
+	list.AddEntry(MkListTextEntry(list, "*not emphasis* and [not link]"))
+
+	tests := []struct {
+		name     string
+		element  md.Element
+		expected string
+	}{
+		{
+			name:     "header",
+			element:  md.NewHeaderBuilder().Text("#1 <b>header</b>").Level(md.HeaderLevelTwo).Build(),
+			expected: "## \\#1 \\<b>header\\</b>\n",
+		},
+		{
+			name:     "text",
+			element:  MkText("2 * 3 = _six_, snake_case & `code` &amp;", md.TextEmphasisNormal),
+			expected: "2 \\* 3 = \\_six\\_, snake_case & \\`code\\` \\&amp;",
+		},
+		{
+			name:     "text block markers",
+			element:  MkText("# not a header\n- not a list\n1. not a list\n> not a quote", md.TextEmphasisNormal),
+			expected: "\\# not a header\n\\- not a list\n1\\. not a list\n\\> not a quote",
+		},
+		{
+			name:     "emphasized text",
+			element:  MkText("bold *text*", md.TextEmphasisBold),
+			expected: "**bold \\*text\\*** ",
+		},
+		{
+			name:     "code text",
+			element:  MkText("a`b*c", md.TextEmphasisCode),
+			expected: "``a`b*c`` ",
+		},
+		{
+			name:     "paragraph",
+			element:  md.NewParagraphBuilder().Elements(MkText("[x]", md.TextEmphasisNormal)).Build(),
+			expected: "\\[x\\]\n",
+		},
+		{
+			name:     "blockquote",
+			element:  md.NewBlockquoteBuilder().Elements(MkText("<script>", md.TextEmphasisNormal)).Build(),
+			expected: "> \\<script>\n\n",
+		},
+		{
+			name:     "list",
+			element:  list,
+			expected: "* \\*not emphasis\\* and \\[not link\\]\n",
+		},
+		{
+			name:     "codeblock is verbatim",
+			element:  MkCode("a | *b* _c_"),
+			expected: "```\na | *b* _c_\n```\n",
+		},
+		{
+			name:     "image",
+			element:  MkImage("http://host/a b.png", "alt [text]", `"quoted" title`),
+			expected: `![alt \[text\]](http://host/a%20b.png "\"quoted\" title")`,
+		},
+		{
+			name:     "rule",
+			element:  MkRule(),
+			expected: "***\n",
+		},
+		{
+			name:     "link",
+			element:  md.NewLinkBuilder().Text("[a]*b*").Url("http://host/(x) y").Build(),
+			expected: "[\\[a\\]\\*b\\*](http://host/%28x%29%20y)",
+		},
+		{
+			name:     "html ref",
+			element:  md.NewHtmlRefBuilder().Name(`a"><script>`).Build(),
+			expected: "\n<a name=\"a&#34;&gt;&lt;script&gt;\"></a>\n",
+		},
+		{
+			name: "table",
+			element: md.NewTableBuilder().Rows(1).Columns(
+				*md.NewColumnBuilder().Index(0).Name("a|b").Rows(row(MkText("x|y\nz", md.TextEmphasisNormal))).Build(),
+				*md.NewColumnBuilder().Index(1).Name("code").Rows(row(MkText("^(a|b)$", md.TextEmphasisCode))).Build(),
+				*md.NewColumnBuilder().Index(2).Name("link").Rows(row(md.NewLinkBuilder().Text("l|t").Url("#a|b").Build())).Build(),
+			).Build(),
+			expected: "| a\\|b      | code        | link           |\n" +
+				"|-----------|-------------|----------------|\n" +
+				"| x\\|y<br>z | `^(a\\|b)$`  | [l\\|t](#a%7Cb) |\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := newTestWriter(Config{})
+			err, _ := renderer.renderElement(tt.element)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, renderer.String())
+		})
+	}
+}
+
+func TestMarkdownRenderer_renderText(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		emphasis md.TextEmphasis
+		expected string
+	}{
+		{name: "italic asterisks", emphasis: md.TextEmphasisItalic, expected: "*text* "},
+		{name: "italic underscores", config: Config{EmphasisSyntax: md.EmphasisSyntaxUnderscores}, emphasis: md.TextEmphasisItalic, expected: "_text_ "},
+		{name: "bold asterisks", emphasis: md.TextEmphasisBold, expected: "**text** "},
+		{name: "bold underscores", config: Config{EmphasisSyntax: md.EmphasisSyntaxUnderscores}, emphasis: md.TextEmphasisBold, expected: "__text__ "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := newTestWriter(tt.config)
+			renderer.renderText(MkText("text", tt.emphasis))
+			assert.Equal(t, tt.expected, renderer.String())
+		})
+	}
+}
+
+func TestMarkdownRenderer_renderCodeblock(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		codeblock *md.Codeblock
+		expected  string
+	}{
+		{
+			name:      "language",
+			codeblock: md.NewCodeblockBuilder().Text("{}").Language("json").Build(),
+			expected:  "```json\n{}\n```\n",
+		},
+		{
+			name:      "fence longer than content backticks",
+			codeblock: md.NewCodeblockBuilder().Text("```go\nfmt.Println(\"````\")\n```\n").Language("markdown").Build(),
+			expected:  "`````markdown\n```go\nfmt.Println(\"````\")\n```\n`````\n",
+		},
+		{
+			name:      "tildas ignore backticks",
+			config:    Config{CodeblockSyntax: md.CodeblockSyntaxTildas},
+			codeblock: md.NewCodeblockBuilder().Text("```\n~~~~").Build(),
+			expected:  "~~~~~\n```\n~~~~\n~~~~~\n",
+		},
+		{
+			name:      "title",
+			codeblock: md.NewCodeblockBuilder().Text("<a/>").Language("xml").Title("Request *example*").Build(),
+			expected:  "*Request \\*example\\**\n\n```xml\n<a/>\n```\n",
+		},
+		{
+			name:      "title with underscores emphasis",
+			config:    Config{EmphasisSyntax: md.EmphasisSyntaxUnderscores},
+			codeblock: md.NewCodeblockBuilder().Text("<a/>").Language("xml").Title("Request _example_").Build(),
+			expected:  "_Request \\_example\\__\n\n```xml\n<a/>\n```\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := newTestWriter(tt.config)
+			err, _ := renderer.renderCodeblock(tt.codeblock)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, renderer.String())
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		err, _ := newTestWriter(Config{}).renderCodeblock(md.NewCodeblockBuilder().Build())
+		assert.Error(t, err)
+	})
+}
+
+func TestMarkdownRenderer_parseRoundTrip(t *testing.T) {
+	renderer := func() *MarkdownRenderer {
+		return NewMarkdownRenderer(DefaultRenderConfig())
+	}
+
+	t.Run("prefix document", func(t *testing.T) {
+		source := "# Service *API*\n\n" +
+			"Intro with **bold**, `code`, [a link](https://example.com/a_b) and snake_case.\n\n" +
+			"## Usage\n\n" +
+			"* step one\n* step two\n     1. nested\n\n" +
+			"> Note: 1. not a list\n\n" +
+			"| Key | Value |\n|:---|---:|\n| a\\|b | 1<br>2 |\n\n" +
+			"```bash\necho \"```\"\n```\n\n" +
+			"<a name=\"anchor\"></a>\n\n---\n"
+
+		document, err := md.Parse(source)
+		assert.NoError(t, err)
+		rendered, err := RenderString(renderer(), document)
+		assert.NoError(t, err)
+
+		reparsed, err := md.Parse(rendered)
+		assert.NoError(t, err)
+		assert.Equal(t, document, reparsed)
+	})
+
+	t.Run("generated document", func(t *testing.T) {
+		request, err := testRequest()
+		assert.NoError(t, err)
+		entries, err := NewDescriptorParser(request).Parse()
+		assert.NoError(t, err)
+		document, err := NewMDGenerator(NewSeededCodegenerator(1)).Generate(entries)
+		assert.NoError(t, err)
+		rendered, err := RenderString(renderer(), document)
+		assert.NoError(t, err)
+
+		// generated sections are merged into a single one by the parser, so blank lines between them may differ
+		parsed, err := md.Parse(rendered)
+		assert.NoError(t, err)
+		rerendered, err := RenderString(renderer(), parsed)
+		assert.NoError(t, err)
+		reparsed, err := md.Parse(rerendered)
+		assert.NoError(t, err)
+		assert.Equal(t, parsed, reparsed)
+	})
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, fmt.Errorf("disk is full")
+}
+
+func TestMarkdownRenderer_Render(t *testing.T) {
+	document := syntheticDocument(50)
+	renderer := NewMarkdownRenderer(DefaultRenderConfig())
+	expected, err := RenderString(renderer, document)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, expected)
+
+	t.Run("repeated calls render the same document", func(t *testing.T) {
+		result, err := RenderString(renderer, document)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("concurrent calls", func(t *testing.T) {
+		var wg sync.WaitGroup
+		results := make([]string, 8)
+		errs := make([]error, len(results))
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = RenderString(renderer, document)
+			}(i)
+		}
+		wg.Wait()
+		for i := range results {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, expected, results[i])
+		}
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		err := renderer.Render(failingWriter{}, document)
+		assert.ErrorContains(t, err, "disk is full")
+	})
+}
+
+// syntheticDocument builds a document shaped like the generated API reference with the specified number of messages
+func syntheticDocument(messages int) *md.Document {
+	document := md.NewDocumentBuilder().Build()
+	toc := md.NewSectionBuilder().Build()
+	list := MkList(false, nil)
+	section := md.NewSectionBuilder().Build()
+	for m := 0; m < messages; m++ {
+		name := fmt.Sprintf("api.Message%d", m)
+		list.AddEntry(MkListEntry(list, MkLink(name[4:], name)))
+
+		section.AddElement(md.NewHtmlRefBuilder().Name(name).Build())
+		section.AddElement(MkHeader(name+" message description:", md.HeaderLevelFour))
+		description := MkParagraph()
+		TextToParagraph(description, "Message with *special* characters_ and | pipes.", md.TextEmphasisNormal)
+		section.AddElement(description)
+
+		table := MkTable(10)
+		for c, column := range []string{"Field", "Type", "Label", "Description"} {
+			rows := make([]md.Row, 10)
+			for r := range rows {
+				rows[r] = *md.NewRowBuilder().Elements(MkText(fmt.Sprintf("%s_%d", column, r), md.TextEmphasisBold)).Build()
+			}
+			table.AddColumn(md.NewColumnBuilder().Index(c).Name(column).Rows(rows...).Build())
+		}
+		section.AddElement(table)
+		section.AddElement(md.NewCodeblockBuilder().Text(fmt.Sprintf("{\n  \"id\": %d\n}", m)).Language("json").Build())
+	}
+	toc.AddElement(list)
+	document.AddSection(toc)
+	document.AddSection(section)
+
+	return document
+}
+
+func BenchmarkMarkdownRenderer_Render(b *testing.B) {
+	for _, messages := range []int{1000, 5000} {
+		document := syntheticDocument(messages)
+		renderer := NewMarkdownRenderer(DefaultRenderConfig())
+		b.Run(strconv.Itoa(messages), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := renderer.Render(io.Discard, document); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(strconv.Itoa(messages)+"/parallel", func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := renderer.Render(io.Discard, document); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
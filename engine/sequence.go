@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	"github.com/rs/zerolog/log"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+)
+
+const (
+	sequenceClient = "Client"
+	sequenceServer = "Server"
+)
+
+// responseName returns full name of the @response message, names without a package are looked up in the package of the request
+func responseName(message *Message, types diagramTypes) (string, bool) {
+	name := message.response
+	if _, ok := types.messages[name]; ok {
+		return name, true
+	}
+	if pkg := message.m.GetPackage(); pkg != "" {
+		if _, ok := types.messages[pkg+"."+name]; ok {
+			return pkg + "." + name, true
+		}
+	}
+
+	return name, false
+}
+
+// response adds the link to the @response message, the name is added as plain text if the message is not found
+func (g *MDGenerator) response(files []ParsedFile, message *Message, section *md.Section) {
+	if message.response == "" {
+		return
+	}
+
+	paragraph := MkParagraph()
+	TextToParagraph(paragraph, g.headings.Response, md.TextEmphasisBold)
+	name, ok := responseName(message, newDiagramTypes(files))
+	if ok {
+		paragraph.AddElement(MkLink(strings.TrimPrefix(name, message.m.GetPackage()+"."), name))
+	} else {
+		log.Warn().Msgf("response message '%s' of '%s' is not found", message.response, message.m.GetFullName())
+		TextToParagraph(paragraph, name, md.TextEmphasisNormal)
+	}
+	section.AddElement(paragraph)
+}
+
+// flowDiagram adds mermaid sequence diagram of the messages with @response, nothing is added if there are none
+func (g *MDGenerator) flowDiagram(messages []*Message, types diagramTypes, section *md.Section) {
+	var builder strings.Builder
+	for _, message := range messages {
+		if message.response == "" {
+			continue
+		}
+		name, _ := responseName(message, types)
+		builder.WriteString(fmt.Sprintf("    %s->>%s: %s\n", sequenceClient, sequenceServer, message.m.GetName()))
+		builder.WriteString(fmt.Sprintf("    %s-->>%s: %s\n", sequenceServer, sequenceClient, strings.TrimPrefix(name, message.m.GetPackage()+".")))
+	}
+	if builder.Len() == 0 {
+		return
+	}
+
+	diagram := "sequenceDiagram\n" +
+		"    participant " + sequenceClient + "\n" +
+		"    participant " + sequenceServer + "\n" +
+		builder.String()
+	section.AddElement(md.NewCodeblockBuilder().Text(diagram).Language("mermaid").Build())
+}
+
+/*
+services adds the services of the file with the notes of their methods. Sequence diagrams are added in the diagram modes,
+without diagrams only the services with method notes are added.
+*/
+func (g *MDGenerator) services(parsedFile ParsedFile, section *md.Section) {
+	diagrams := g.diagrams != DiagramModeNone
+	services := arrayutils.Filter(parsedFile.services, func(v *Service) bool {
+		return diagrams || hasMethodNotes(v)
+	})
+	if len(services) == 0 {
+		return
+	}
+
+	g.header(g.headings.Services, 3, section)
+	for _, service := range services {
+		g.header(service.s.GetFullName()+" service:", 4, section)
+		if service.description != "" {
+			section.AddElement(md.NewTextBuilder().Text(service.description).Build())
+		}
+		methodNotes(&service, section)
+		if diagrams && len(service.methods) > 0 {
+			section.AddElement(md.NewCodeblockBuilder().Text(serviceDiagram(&service)).Language("mermaid").Build())
+		}
+	}
+}
+
+func hasMethodNotes(service *Service) bool {
+	for _, method := range service.methods {
+		if method.deprecated.Present() || method.since != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// methodNotes adds the list of service methods, which are deprecated or have @since version, with their notes
+func methodNotes(service *Service, section *md.Section) {
+	list := MkList(false, nil)
+	for _, method := range service.methods {
+		if !method.deprecated.Present() && method.since == "" {
+			continue
+		}
+		paragraph := MkParagraph()
+		TextToParagraph(paragraph, method.d.GetName(), nameEmphasis(method.deprecated))
+		method.deprecated.IfPresent(func(reason string) {
+			TextToParagraph(paragraph, deprecationNote(reason), md.TextEmphasisItalic)
+		})
+		if method.since != "" {
+			TextToParagraph(paragraph, "since "+method.since, md.TextEmphasisItalic)
+		}
+		list.AddEntry(MkListEntry(list, paragraph))
+	}
+	if len(list.GetEntries()) > 0 {
+		section.AddElement(list)
+	}
+}
+
+/*
+serviceDiagram returns mermaid sequenceDiagram of the service methods. Streamed requests or responses are wrapped into loops,
+bidirectional streams have both of them in the same loop.
+*/
+func serviceDiagram(service *Service) string {
+	pkg := service.s.GetPackage()
+	participant := service.s.GetName()
+
+	var builder strings.Builder
+	builder.WriteString("sequenceDiagram\n")
+	builder.WriteString("    participant " + sequenceClient + "\n")
+	builder.WriteString("    participant " + participant + "\n")
+	for _, method := range service.methods {
+		d := method.d
+		input := strings.TrimPrefix(strings.TrimPrefix(d.GetInputType(), "."), pkg+".")
+		output := strings.TrimPrefix(strings.TrimPrefix(d.GetOutputType(), "."), pkg+".")
+		request := fmt.Sprintf("%s->>%s: %s(%s)\n", sequenceClient, participant, d.GetName(), input)
+		response := fmt.Sprintf("%s-->>%s: %s\n", participant, sequenceClient, output)
+		loop := fmt.Sprintf("    loop %s stream\n", d.GetName())
+
+		switch {
+		case d.GetClientStreaming() && d.GetServerStreaming():
+			builder.WriteString(loop)
+			builder.WriteString("        " + request)
+			builder.WriteString("        " + response)
+			builder.WriteString("    end\n")
+		case d.GetClientStreaming():
+			builder.WriteString(loop)
+			builder.WriteString("        " + request)
+			builder.WriteString("    end\n")
+			builder.WriteString("    " + response)
+		case d.GetServerStreaming():
+			builder.WriteString("    " + request)
+			builder.WriteString(loop)
+			builder.WriteString("        " + response)
+			builder.WriteString("    end\n")
+		default:
+			builder.WriteString("    " + request)
+			builder.WriteString("    " + response)
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/kordax/basic-utils/opt"
+)
+
+func TestFlagValue(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		flags    []string
+		expected string
+		found    bool
+	}{
+		{"value", []string{"autocode[json]", "response=TokenResponse"}, "TokenResponse", true},
+		{"trailing comment", []string{"response=api.TokenResponse\n * sent on success */"}, "api.TokenResponse", true},
+		{"missing", []string{"publish"}, "", false},
+		{"empty", []string{"response="}, "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			value, found := flagValue(tc.flags, ResponseMarker)
+			assert.Equal(t, tc.expected, value)
+			assert.Equal(t, tc.found, found)
+		})
+	}
+}
+
+func TestServiceDiagram(t *testing.T) {
+	request, err := testRequest()
+	require.NoError(t, err)
+	entries, err := NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+	require.Len(t, entries[0].services, 1)
+
+	result := serviceDiagram(&entries[0].services[0])
+	assert.Contains(t, result, "sequenceDiagram\n    participant Client\n    participant AuthService\n")
+	assert.Contains(t, result, "    Client->>AuthService: Token(TokenRequest)\n    AuthService-->>Client: TokenResponse\n")
+	assert.Contains(t, result, "    Client->>AuthService: ListServers(ListServersRequest)\n"+
+		"    loop ListServers stream\n"+
+		"        AuthService-->>Client: ListServersResponse\n"+
+		"    end\n")
+}
+
+func TestMDGenerator_flowDiagram(t *testing.T) {
+	request, err := testRequest()
+	require.NoError(t, err)
+	entries, err := NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+	for _, entry := range entries[0].entries {
+		if entry.t == EntryTypeMessage && entry.msg.m.GetName() == "RegistrationRequest" {
+			entry.msg.response = "RegistrationResponse"
+		}
+	}
+
+	generator := NewMDGenerator(NewCodegenerator())
+	generator.SetDiagrams(DiagramModeHeader)
+	document, err := generator.Generate(entries)
+	require.NoError(t, err)
+	result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), document)
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "    Client->>Server: RegistrationRequest\n    Server-->>Client: RegistrationResponse\n")
+	assert.Contains(t, result, "**Response:** [RegistrationResponse](#doc_generator_test-registrationresponse)")
+	assert.Contains(t, result, "#### doc_generator_test.AuthService service:")
+
+	t.Run("unresolved response", func(t *testing.T) {
+		for _, entry := range entries[0].entries {
+			if entry.t == EntryTypeMessage && entry.msg.m.GetName() == "RegistrationRequest" {
+				entry.msg.response = "MissingResponse"
+			}
+		}
+		document, err := NewMDGenerator(NewCodegenerator()).Generate(entries)
+		require.NoError(t, err)
+		result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), document)
+		require.NoError(t, err)
+
+		assert.Contains(t, result, "**Response:** MissingResponse\n")
+		assert.NotContains(t, result, "](#MissingResponse)")
+	})
+}
+
+func TestMDGenerator_methodNotes(t *testing.T) {
+	request, err := testRequest()
+	require.NoError(t, err)
+	entries, err := NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+
+	render := func(mode DiagramMode) string {
+		generator := NewMDGenerator(NewCodegenerator())
+		generator.SetDiagrams(mode)
+		document, err := generator.Generate(entries)
+		require.NoError(t, err)
+		result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), document)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("no notes", func(t *testing.T) {
+		assert.NotContains(t, render(DiagramModeNone), "AuthService")
+	})
+
+	entries[0].services[0].methods[0].deprecated = opt.Of("use Register")
+	for name, mode := range map[string]DiagramMode{"without diagrams": DiagramModeNone, "with diagrams": DiagramModeFile} {
+		t.Run(name, func(t *testing.T) {
+			result := render(mode)
+			assert.Contains(t, result, "#### doc_generator_test.AuthService service:")
+			assert.Contains(t, result, "* ~~Token~~ *Deprecated: use Register*")
+			assert.Equal(t, mode != DiagramModeNone, strings.Contains(result, "participant AuthService"))
+		})
+	}
+}
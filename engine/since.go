@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+)
+
+/*
+FilterAsOf returns the files as of the API version, i.e. without messages, fields, enums, enum values and service methods,
+which @since version is newer than the given one. The parsed files are not modified.
+*/
+func FilterAsOf(parsedFiles []ParsedFile, version string) []ParsedFile {
+	result := make([]ParsedFile, 0, len(parsedFiles))
+	for _, file := range parsedFiles {
+		file.entries = entriesAsOf(file.entries, version)
+		services := make([]Service, 0, len(file.services))
+		for _, service := range file.services {
+			methods := make([]ServiceMethod, 0, len(service.methods))
+			for _, method := range service.methods {
+				if availableAsOf(method.since, version) {
+					methods = append(methods, method)
+				}
+			}
+			service.methods = methods
+			services = append(services, service)
+		}
+		file.services = services
+		result = append(result, file)
+	}
+
+	return result
+}
+
+func entriesAsOf(entries []Entry, version string) []Entry {
+	result := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		switch {
+		case entry.t == EntryTypeMessage && entry.msg != nil:
+			if !availableAsOf(entry.msg.since, version) {
+				continue
+			}
+			msg := *entry.msg
+			msg.fields = make([]MessageField, 0, len(entry.msg.fields))
+			for _, field := range entry.msg.fields {
+				if availableAsOf(field.since, version) {
+					msg.fields = append(msg.fields, field)
+				}
+			}
+			msg.entries = entriesAsOf(entry.msg.entries, version)
+			entry.msg = &msg
+		case entry.t == EntryTypeEnum && entry.enum != nil:
+			if !availableAsOf(entry.enum.since, version) {
+				continue
+			}
+			enum := *entry.enum
+			enum.values = make([]EnumField, 0, len(entry.enum.values))
+			for _, value := range entry.enum.values {
+				if availableAsOf(value.since, version) {
+					enum.values = append(enum.values, value)
+				}
+			}
+			entry.enum = &enum
+		}
+		result = append(result, entry)
+	}
+
+	return result
+}
+
+func availableAsOf(since, version string) bool {
+	return since == "" || compareVersions(since, version) <= 0
+}
+
+/*
+compareVersions compares dot separated versions with an optional 'v' prefix, e.g. '2.3' < 'v2.10.1'. Numeric parts are compared
+as numbers, other ones as strings, missing parts are zeros.
+*/
+func compareVersions(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := "0", "0"
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+		aNum, aErr := strconv.Atoi(aPart)
+		bNum, bErr := strconv.Atoi(bPart)
+		switch {
+		case aErr == nil && bErr == nil && aNum != bNum:
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && aPart != bPart:
+			return strings.Compare(aPart, bPart)
+		}
+	}
+
+	return 0
+}
+
+// since adds the @since version paragraph of the message or enum
+func since(version string, section *md.Section) {
+	if version == "" {
+		return
+	}
+
+	paragraph := MkParagraph()
+	TextToParagraph(paragraph, "Since:", md.TextEmphasisBold)
+	TextToParagraph(paragraph, version, md.TextEmphasisNormal)
+	section.AddElement(paragraph)
+}
+
+// sinceBadge returns the table of contents entry of the type with 'new in <version>' badge
+func sinceBadge(link *md.Link, version string) md.Element {
+	if version == "" {
+		return link
+	}
+
+	paragraph := MkParagraph()
+	paragraph.AddElement(link)
+	TextToParagraph(paragraph, " ", md.TextEmphasisNormal)
+	TextToParagraph(paragraph, "new in "+version, md.TextEmphasisItalic)
+	return paragraph
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func sinceTestFiles(t *testing.T) []ParsedFile {
+	comment := func(since string, path ...int32) *descriptorpb.SourceCodeInfo_Location {
+		return &descriptorpb.SourceCodeInfo_Location{Path: path, Span: []int32{1, 0, 10}, TrailingComments: proto.String(" @since=" + since + "\n")}
+	}
+
+	return diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		common.EnumType[0].Value = append(common.EnumType[0].Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String("EUR"), Number: proto.Int32(1)})
+		common.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{
+			comment("2.0", 4, 0),
+			comment("2.3", 4, 0, 2, 1),
+			comment("v3.0", 5, 0, 2, 1),
+		}}
+		orders.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{comment("3.0", 4, 1)}}
+	})
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.3", "2.3", 0},
+		{"2.3", "2.3.0", 0},
+		{"v2.3", "2.10", -1},
+		{"3.0", "2.10.1", 1},
+		{"2.3.1", "2.3", 1},
+		{"1.0-beta", "1.0-rc", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, compareVersions(tt.a, tt.b))
+		})
+	}
+}
+
+func TestFilterAsOf(t *testing.T) {
+	files := sinceTestFiles(t)
+	api := newApiIndex(files)
+	require.Equal(t, "2.3", api.messages["common.Money"].fields[1].since)
+
+	t.Run("as of 2.0", func(t *testing.T) {
+		filtered := newApiIndex(FilterAsOf(files, "2.0"))
+		assert.Contains(t, filtered.messages, "common.Money")
+		assert.NotContains(t, filtered.messages, "shop.Item")
+		assert.Len(t, filtered.messages["common.Money"].fields, 1)
+		assert.Len(t, filtered.enums["common.Currency"].values, 1)
+	})
+
+	t.Run("as of 3.0", func(t *testing.T) {
+		filtered := newApiIndex(FilterAsOf(files, "3.0"))
+		assert.Len(t, filtered.messages, 3)
+		assert.Len(t, filtered.messages["common.Money"].fields, 2)
+		assert.Len(t, filtered.enums["common.Currency"].values, 2)
+	})
+
+	t.Run("source is kept", func(t *testing.T) {
+		FilterAsOf(files, "1.0")
+		assert.Len(t, newApiIndex(files).messages["common.Money"].fields, 2)
+	})
+}
+
+func TestMDGenerator_since(t *testing.T) {
+	generator := NewMDGenerator(NewCodegenerator())
+	generator.SetSinceBadges(true)
+	document, err := generator.Generate(sinceTestFiles(t))
+	require.NoError(t, err)
+	result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), document)
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "     * [Item](#shop-item) *new in 3.0* \n")
+	assert.Contains(t, result, "**Since:** 2.0\n")
+	assert.Contains(t, result, "| Field         | Type                         | Label | Description | Since |")
+	assert.Contains(t, result, "| **currency**  | [Currency](#common-currency) |       |             | 2.3   |")
+	assert.Contains(t, result, "| **EUR**  |             | v3.0  |")
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+)
+
+// Region names of generated sections, proto file sections are named after the file name, e.g. 'api/service.proto'
+const (
+	RegionDocument        = ""
+	RegionTableOfContents = "toc"
+	RegionEnums           = "enums"
+)
+
+const SpliceBeginMarker = "pbmd:begin"
+const SpliceEndMarker = "pbmd:end"
+
+var spliceMarkerPattern = regexp.MustCompile(`<!--[ \t]*pbmd:(begin|end)(?:[ \t]+([^\s>]+))?[ \t]*-->`)
+
+// spliceFencePattern matches code fences of any indentation, so fences nested in list items are found as well
+var spliceFencePattern = regexp.MustCompile("^[ \t]*(`{3,}|~{3,})")
+
+/*
+RenderRegions renders the whole document as unnamed region and each named section as a separate region.
+*/
+func RenderRegions(config *Config, document *md.Document) (map[string]string, error) {
+	result := make(map[string]string)
+	renderer := NewMarkdownRenderer(config)
+	content, err := RenderString(renderer, document)
+	if err != nil {
+		return nil, err
+	}
+	result[RegionDocument] = content
+
+	for _, section := range document.GetSections() {
+		if section.GetName() == "" {
+			continue
+		}
+		region := md.NewDocumentBuilder().Build()
+		region.AddSection(&section)
+		content, err = RenderString(renderer, region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render region '%s': %s", section.GetName(), err.Error())
+		}
+		result[section.GetName()] = content
+	}
+
+	return result, nil
+}
+
+/*
+Splice replaces content between '<!-- pbmd:begin -->' and '<!-- pbmd:end -->' markers of the target with the generated regions,
+named regions are marked as '<!-- pbmd:begin toc -->' ... '<!-- pbmd:end toc -->'. Markers and the content outside of them are kept as is.
+Markers in fenced or indented code blocks are examples, they are ignored.
+*/
+func Splice(target string, regions map[string]string) (string, error) {
+	markers := spliceMarkers(target)
+	if len(markers) == 0 {
+		return "", fmt.Errorf("no '<!-- %s -->' markers found", SpliceBeginMarker)
+	}
+
+	var builder strings.Builder
+	last := 0
+	for i := 0; i < len(markers); i += 2 {
+		begin := markers[i]
+		kind, name := target[begin[2]:begin[3]], markerName(target, begin)
+		if kind != "begin" {
+			return "", fmt.Errorf("unexpected '%s' marker at line %d", SpliceEndMarker, lineOf(target, begin[0]))
+		}
+		if i+1 >= len(markers) {
+			return "", fmt.Errorf("region '%s' at line %d is not closed", name, lineOf(target, begin[0]))
+		}
+		end := markers[i+1]
+		if target[end[2]:end[3]] != "end" || markerName(target, end) != name {
+			return "", fmt.Errorf("region '%s' at line %d is closed by mismatched marker at line %d", name, lineOf(target, begin[0]), lineOf(target, end[0]))
+		}
+		content, ok := regions[name]
+		if !ok {
+			return "", fmt.Errorf("unknown region '%s' at line %d", name, lineOf(target, begin[0]))
+		}
+
+		builder.WriteString(target[last:begin[1]])
+		builder.WriteString("\n")
+		builder.WriteString(strings.Trim(content, "\n"))
+		builder.WriteString("\n")
+		last = end[0]
+	}
+	builder.WriteString(target[last:])
+
+	return builder.String(), nil
+}
+
+func spliceMarkers(target string) [][]int {
+	code := codeRanges(target)
+	var result [][]int
+	for _, marker := range spliceMarkerPattern.FindAllStringSubmatchIndex(target, -1) {
+		inCode := false
+		for _, r := range code {
+			if marker[0] >= r[0] && marker[0] < r[1] {
+				inCode = true
+				break
+			}
+		}
+		if !inCode {
+			result = append(result, marker)
+		}
+	}
+
+	return result
+}
+
+// codeRanges returns the offsets of fenced and indented code blocks, an unclosed fence runs to the end of the text
+func codeRanges(text string) [][2]int {
+	var result [][2]int
+	var fence string
+	start, offset := 0, 0
+	indented, previousBlank := false, true
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				result = append(result, [2]int{start, offset + len(line)})
+				fence = ""
+			}
+		case spliceFencePattern.MatchString(line):
+			fence = spliceFencePattern.FindStringSubmatch(line)[1]
+			start = offset
+			indented = false
+		case trimmed != "" && (previousBlank || indented) && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")):
+			result = append(result, [2]int{offset, offset + len(line)})
+			indented = true
+		case trimmed != "":
+			indented = false
+		}
+		previousBlank = trimmed == ""
+		offset += len(line)
+	}
+	if fence != "" {
+		result = append(result, [2]int{start, len(text)})
+	}
+
+	return result
+}
+
+func markerName(target string, marker []int) string {
+	if marker[4] == -1 {
+		return RegionDocument
+	}
+
+	return target[marker[4]:marker[5]]
+}
+
+func lineOf(text string, offset int) int {
+	return strings.Count(text[:offset], "\n") + 1
+}
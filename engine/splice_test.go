@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplice(t *testing.T) {
+	regions := map[string]string{
+		RegionDocument:        "generated\n",
+		RegionTableOfContents: "* toc\n",
+	}
+
+	t.Run("replaces regions and keeps the rest", func(t *testing.T) {
+		target := "# Readme\n\n<!-- pbmd:begin toc -->\nold toc\n<!-- pbmd:end toc -->\n\n" +
+			"intro\n\n<!-- pbmd:begin -->\nold\ncontent\n<!-- pbmd:end -->\n\n## License\n"
+
+		result, err := Splice(target, regions)
+		require.NoError(t, err)
+		assert.Equal(t, "# Readme\n\n<!-- pbmd:begin toc -->\n* toc\n<!-- pbmd:end toc -->\n\n"+
+			"intro\n\n<!-- pbmd:begin -->\ngenerated\n<!-- pbmd:end -->\n\n## License\n", result)
+
+		again, err := Splice(result, regions)
+		require.NoError(t, err)
+		assert.Equal(t, result, again)
+	})
+
+	t.Run("ignores markers in code blocks", func(t *testing.T) {
+		example := "```markdown\n<!-- pbmd:begin toc -->\n<!-- pbmd:end -->\n```\n\n" +
+			"1. List item\n\n   ~~~~\n   <!-- pbmd:begin -->\n   ~~~\n   ~~~~\n\n" +
+			"Indented example:\n\n    <!-- pbmd:begin other.proto -->\n\n    <!-- pbmd:end other.proto -->\n\n"
+		target := example + "<!-- pbmd:begin -->\nold\n<!-- pbmd:end -->\n"
+
+		result, err := Splice(target, regions)
+		require.NoError(t, err)
+		assert.Equal(t, example+"<!-- pbmd:begin -->\ngenerated\n<!-- pbmd:end -->\n", result)
+
+		_, err = Splice("```\n<!-- pbmd:begin -->\n<!-- pbmd:end -->\n", regions)
+		assert.ErrorContains(t, err, "no '<!-- pbmd:begin -->' markers found")
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		err    string
+	}{
+		{"no markers", "# Readme\n", "no '<!-- pbmd:begin -->' markers found"},
+		{"not closed", "a\n<!-- pbmd:begin -->\nb", "is not closed"},
+		{"mismatched end", "<!-- pbmd:begin toc -->\n<!-- pbmd:end -->", "closed by mismatched marker at line 2"},
+		{"end without begin", "<!-- pbmd:end -->", "unexpected 'pbmd:end' marker at line 1"},
+		{"unknown region", "<!-- pbmd:begin other.proto -->\n<!-- pbmd:end other.proto -->", "unknown region 'other.proto'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Splice(tt.target, regions)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestRenderRegions(t *testing.T) {
+	request, err := testRequest()
+	require.NoError(t, err)
+	entries, err := NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+	document, err := NewMDGenerator(NewCodegenerator()).Generate(entries)
+	require.NoError(t, err)
+
+	regions, err := RenderRegions(DefaultRenderConfig(), document)
+	require.NoError(t, err)
+	for _, section := range document.GetSections() {
+		assert.Contains(t, regions, section.GetName())
+	}
+	assert.Contains(t, regions[RegionDocument], regions[RegionTableOfContents])
+	assert.Contains(t, regions[RegionEnums], "## Enums")
+
+	// unnamed sections, e.g. the prefix ones, are part of the document region only
+	prefix := md.NewDocumentBuilder().Build()
+	prefix.AddSection(md.NewSectionBuilder().Elements(MkHeader("Prefix", md.HeaderLevelOne)).Build())
+	regions, err = RenderRegions(DefaultRenderConfig(), prefix)
+	require.NoError(t, err)
+	assert.Len(t, regions, 1)
+}
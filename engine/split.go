@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+)
+
+type SplitMode string
+
+const (
+	SplitModeNone    SplitMode = ""
+	SplitModeFile    SplitMode = "file"
+	SplitModePackage SplitMode = "package"
+)
+
+// IndexPageName is the name of the page, which lists all the other pages
+const IndexPageName = "index.md"
+
+func ParseSplitMode(mode string) (SplitMode, error) {
+	switch SplitMode(strings.ToLower(mode)) {
+	case SplitModeNone:
+		return SplitModeNone, nil
+	case SplitModeFile:
+		return SplitModeFile, nil
+	case SplitModePackage:
+		return SplitModePackage, nil
+	}
+
+	return SplitModeNone, fmt.Errorf("unsupported split mode: '%s', supported modes are: file, package", mode)
+}
+
+// Page is a single document of the split output, name is the page file name relative to the output directory
+type Page struct {
+	Name     string
+	Document *md.Document
+}
+
+type pageFiles struct {
+	name  string
+	title string
+	files []ParsedFile
+}
+
+/*
+GenerateSplit generates one page per proto file or package and an index page, which contains the prefix and the table of contents
+of all pages. Type links between pages are resolved to 'page.md#anchor', the scalar value types table is placed on the index page.
+*/
+func (g *MDGenerator) GenerateSplit(parsedFiles []ParsedFile, mode SplitMode) ([]Page, error) {
+	if mode == SplitModeNone {
+		document, err := g.Generate(parsedFiles)
+		if err != nil {
+			return nil, err
+		}
+		return []Page{{Name: IndexPageName, Document: document}}, nil
+	}
+
+	sort.SliceStable(parsedFiles, func(i, j int) bool {
+		return parsedFiles[i].index < parsedFiles[j].index
+	})
+	pages := groupPages(parsedFiles, mode)
+	pageOf := make(map[string]string)
+	for _, page := range pages {
+		for _, file := range page.files {
+			pageOf[file.Filename()] = page.name
+		}
+	}
+	links := newLinkResolver(parsedFiles, func(file ParsedFile) string {
+		return pageOf[file.Filename()]
+	})
+	links.scalarsPage = IndexPageName
+	refs := buildReferenceIndex(parsedFiles)
+
+	index := md.NewDocumentBuilder().Build()
+	if g.prefix != nil {
+		index.AddSection(prefixSection(g.prefixSource))
+	}
+	tocSection := md.NewSectionBuilder().Name(RegionTableOfContents).Build()
+	g.pagesTableOfContents(pages, tocSection)
+	index.AddSection(tocSection)
+
+	result := []Page{{Name: IndexPageName, Document: index}}
+	var scalars []string
+	for _, page := range pages {
+		document := md.NewDocumentBuilder().Build()
+		for _, file := range page.files {
+			section, err := g.fileSection(parsedFiles, file, refs)
+			if err != nil {
+				return nil, err
+			}
+			document.AddSection(section)
+		}
+
+		_, enums := collectEntries(page.files)
+		if len(enums) > 0 {
+			section, err := g.enumSection(enums, refs)
+			if err != nil {
+				return nil, err
+			}
+			document.AddSection(section)
+		}
+		for _, scalar := range links.resolve(document, page.name) {
+			if arrayutils.Contains(scalar, scalars) == -1 {
+				scalars = append(scalars, scalar)
+			}
+		}
+		result = append(result, Page{Name: page.name, Document: document})
+	}
+
+	links.resolve(index, IndexPageName)
+	if len(scalars) > 0 {
+		index.AddSection(scalarSection(scalars, g.headings.ScalarTypes))
+	}
+
+	return result, nil
+}
+
+/*
+groupPages groups the files into pages by file name or package. Different names, which are flattened into the same page name,
+e.g. 'api/v1_x.proto' and 'api_v1/x.proto', get a numeric suffix in the order of the files: 'api_v1_x.md' and 'api_v1_x_2.md'.
+*/
+func groupPages(parsedFiles []ParsedFile, mode SplitMode) []*pageFiles {
+	var pages []*pageFiles
+	byKey := make(map[string]*pageFiles)
+	taken := map[string]bool{IndexPageName: true}
+	for _, file := range parsedFiles {
+		var key, title string
+		switch mode {
+		case SplitModePackage:
+			key = file.Package()
+			title = file.Package()
+		default:
+			key = strings.TrimSuffix(file.Filename(), path.Ext(file.Filename()))
+			title = file.Title()
+			if title == "" {
+				title = file.Filename()
+			}
+		}
+
+		page, ok := byKey[key]
+		if !ok {
+			name := pageName(key)
+			for i := 2; taken[name]; i++ {
+				name = pageName(fmt.Sprintf("%s_%d", key, i))
+			}
+			taken[name] = true
+			page = &pageFiles{name: name, title: title}
+			byKey[key] = page
+			pages = append(pages, page)
+		}
+		page.files = append(page.files, file)
+	}
+
+	return pages
+}
+
+// pageName flattens the file or package name into a page file name, e.g. 'api/v1/service' becomes 'api_v1_service.md'
+func pageName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_", " ", "_").Replace(name)
+	if name == "" || name+".md" == IndexPageName {
+		name = "_" + name
+	}
+
+	return name + ".md"
+}
+
+func (g *MDGenerator) pagesTableOfContents(pages []*pageFiles, section *md.Section) {
+	toc := MkList(false, nil)
+	entry := MkListTextEntry(toc, g.headings.TableOfContents)
+	if g.prefix != nil {
+		if headings := prefixHeadings(g.prefix); len(headings) > 0 {
+			entry.AddSublist(headingListRecursive(headings, MkList(false, toc)))
+		}
+	}
+
+	pageList := MkList(false, toc)
+	for _, page := range pages {
+		pageEntry := MkListEntry(pageList, md.NewLinkBuilder().Text(page.title).Url(page.name).Build())
+		entries, _ := collectEntries(page.files)
+		entries = g.tocEntries(entries)
+		if len(entries) > 0 {
+			pageEntry.AddSublist(g.list(entries, pageList, false, 0))
+		}
+		pageList.AddEntry(pageEntry)
+	}
+	entry.AddSublist(pageList)
+	toc.AddEntry(entry)
+	section.AddElement(toc)
+}
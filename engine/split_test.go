@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	plugingo "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const splitTestCommonProto = `syntax = "proto3";
+package common;
+
+message Money {
+  int64 amount = 1;
+  Currency currency = 2;
+}
+
+enum Currency {
+  USD = 0;
+}
+`
+
+const splitTestOrdersProto = `syntax = "proto3";
+package shop;
+
+import "api/common.proto";
+
+message Order {
+  common.Money price = 1;
+  Item item = 2;
+}
+
+message Item {
+  string name = 1;
+}
+`
+
+// splitTestRequest returns two files of different packages, where 'orders.proto' refers to types of 'common.proto'
+func splitTestRequest(t *testing.T) *plugingo.CodeGeneratorRequest {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "api"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api", "common.proto"), []byte(splitTestCommonProto), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api", "orders.proto"), []byte(splitTestOrdersProto), 0644))
+
+	field := func(name string, number int32, t descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   t.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	common := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("api/common.proto"),
+		Package: proto.String("common"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Money"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("amount", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				field("currency", 2, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".common.Currency"),
+			},
+		}},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name:  proto.String("Currency"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{{Name: proto.String("USD"), Number: proto.Int32(0)}},
+		}},
+	}
+	orders := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("api/orders.proto"),
+		Package:    proto.String("shop"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"api/common.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Order"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("price", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".common.Money"),
+				field("item", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".shop.Item"),
+			},
+		}, {
+			Name:  proto.String("Item"),
+			Field: []*descriptorpb.FieldDescriptorProto{field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")},
+		}},
+	}
+
+	return &plugingo.CodeGeneratorRequest{
+		FileToGenerate: []string{"api/common.proto", "api/orders.proto"},
+		Parameter:      proto.String("Mcommon.proto=" + dir + ";Morders.proto=" + dir),
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{common, orders},
+	}
+}
+
+func TestMDGenerator_GenerateSplit(t *testing.T) {
+	entries, err := NewDescriptorParser(splitTestRequest(t)).Parse()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	render := func(page Page) string {
+		result, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), page.Document)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("per file", func(t *testing.T) {
+		pages, err := NewMDGenerator(NewCodegenerator()).GenerateSplit(entries, SplitModeFile)
+		require.NoError(t, err)
+		require.Len(t, pages, 3)
+		assert.Equal(t, IndexPageName, pages[0].Name)
+		assert.Equal(t, "api_common.md", pages[1].Name)
+		assert.Equal(t, "api_orders.md", pages[2].Name)
+
+		index := render(pages[0])
+		assert.Contains(t, index, "[api/common.proto](api_common.md)")
+		assert.Contains(t, index, "[Money](api_common.md#common-money)")
+		assert.Contains(t, index, "[Order](api_orders.md#shop-order)")
+
+		common := render(pages[1])
+		assert.Contains(t, common, "[Currency](#common-currency)")
+		assert.Contains(t, common, "## Enums")
+
+		orders := render(pages[2])
+		assert.Contains(t, orders, "[common.Money](api_common.md#common-money)")
+		assert.Contains(t, orders, "[Item](#shop-item)")
+		assert.NotContains(t, orders, "## Enums")
+	})
+
+	t.Run("per package", func(t *testing.T) {
+		pages, err := NewMDGenerator(NewCodegenerator()).GenerateSplit(entries, SplitModePackage)
+		require.NoError(t, err)
+		require.Len(t, pages, 3)
+		assert.Equal(t, "common.md", pages[1].Name)
+		assert.Equal(t, "shop.md", pages[2].Name)
+		assert.Contains(t, render(pages[2]), "[common.Money](common.md#common-money)")
+	})
+
+	t.Run("single document links stay on the page", func(t *testing.T) {
+		document, err := NewMDGenerator(NewCodegenerator()).Generate(entries)
+		require.NoError(t, err)
+		result := render(Page{Document: document})
+		assert.Contains(t, result, "[common.Money](#common-money)")
+		assert.Contains(t, result, "[int64](#scalar-int64)")
+		assert.Contains(t, result, "## Scalar Value Types")
+		assert.NotContains(t, result, ".md#")
+	})
+}
+
+func TestGroupPages(t *testing.T) {
+	files := []ParsedFile{
+		{filename: "api/v1_x.proto", pkg: "api.v1"},
+		{filename: "api_v1/x.proto", pkg: "api.v1"},
+		{filename: "api_v1_x.proto", pkg: "api_v1"},
+		{filename: "api/v1/x_2.proto", pkg: "api_v1"},
+		{filename: "index.proto", pkg: "index"},
+	}
+	names := func(pages []*pageFiles) []string {
+		return arrayutils.Map(pages, func(page **pageFiles) string {
+			return (*page).name
+		})
+	}
+
+	t.Run("per file", func(t *testing.T) {
+		pages := groupPages(files, SplitModeFile)
+		assert.Equal(t, []string{"api_v1_x.md", "api_v1_x_2.md", "api_v1_x_3.md", "api_v1_x_2_2.md", "_index.md"}, names(pages))
+		assert.Equal(t, "api_v1/x.proto", pages[1].title)
+	})
+
+	t.Run("per package", func(t *testing.T) {
+		pages := groupPages(files, SplitModePackage)
+		assert.Equal(t, []string{"api.v1.md", "api_v1.md", "_index.md"}, names(pages))
+		assert.Len(t, pages[0].files, 2)
+	})
+}
+
+func TestParseSplitMode(t *testing.T) {
+	mode, err := ParseSplitMode("Package")
+	assert.NoError(t, err)
+	assert.Equal(t, SplitModePackage, mode)
+	_, err = ParseSplitMode("service")
+	assert.Error(t, err)
+}
@@ -0,0 +1,497 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/kordax/pb-md5-generator/engine/md"
+	"github.com/rs/zerolog/log"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+)
+
+// DocumentTemplate is the template, which renders the whole document, templates redefine it or the blocks it's built of
+const DocumentTemplate = "document"
+
+/*
+TemplateGenerator generates the document with Go text/template. The default template reproduces the MDGenerator layout
+without diagrams, services and @response links, templates added with Parse redefine its blocks with
+{{define "message"}}...{{end}} etc. The template output is written as is, so raw HTML is kept and the render syntax
+doesn't apply to it. Type links are resolved by the link function and the scalar types it links to are listed after the output.
+*/
+type TemplateGenerator struct {
+	codegen      *Codegenerator
+	template     *template.Template
+	prefix       *md.Document
+	prefixSource string
+	options      TemplateOptions
+	headings     Headings
+}
+
+// TemplateOptions are the generator settings available to the templates
+type TemplateOptions struct {
+	HideDeprecated bool
+	SinceBadges    bool
+}
+
+// TemplateData is the root object of the templates
+type TemplateData struct {
+	Headings Headings
+	Options  TemplateOptions
+	// PrefixHeadings are the headings of the prefix document in the document order
+	PrefixHeadings []TemplateHeading
+	Files          []TemplateFile
+	// Messages and Enums are the ones of all files in the declaration order
+	Messages []*TemplateMessage
+	Enums    []*TemplateEnum
+}
+
+type TemplateHeading struct {
+	Text   string
+	Anchor string
+	// Depth is the nesting level of the heading in the table of contents, starting from 0
+	Depth int
+}
+
+type TemplateFile struct {
+	Name     string
+	Title    string
+	Package  string
+	Messages []*TemplateMessage
+	Enums    []*TemplateEnum
+	Services []TemplateService
+}
+
+type TemplateMessage struct {
+	Name     string
+	FullName string
+	Anchor   string
+	// Header is the @header group of the message
+	Header            string
+	Description       string
+	Deprecated        bool
+	DeprecationReason string
+	Since             string
+	Response          string
+	Fields            []TemplateField
+	UsedBy            []TemplateReference
+	// Example is the @code or @autocode example, it's nil if the message has none
+	Example *TemplateCode
+	// Has* report whether any field has the value, i.e. the optional columns of the fields table are shown
+	HasMin       bool
+	HasMax       bool
+	HasMaxLength bool
+	HasPattern   bool
+	HasSince     bool
+}
+
+type TemplateField struct {
+	Name   string
+	Number int32
+	// Type is the type name relative to the message package, TypeName is the full name links point to
+	Type              string
+	TypeName          string
+	Label             string
+	Description       string
+	Deprecated        bool
+	DeprecationReason string
+	Since             string
+	Min               string
+	Max               string
+	MaxLength         string
+	Pattern           string
+}
+
+type TemplateEnum struct {
+	Name              string
+	FullName          string
+	Anchor            string
+	Description       string
+	Deprecated        bool
+	DeprecationReason string
+	Since             string
+	Values            []TemplateEnumValue
+	UsedBy            []TemplateReference
+	HasSince          bool
+}
+
+type TemplateEnumValue struct {
+	Name              string
+	Number            int32
+	Description       string
+	Deprecated        bool
+	DeprecationReason string
+	Since             string
+}
+
+// TemplateReference is the field, which refers to the message or enum
+type TemplateReference struct {
+	Message     string
+	MessageName string
+	Field       string
+}
+
+type TemplateService struct {
+	Name        string
+	FullName    string
+	Description string
+	Methods     []TemplateMethod
+}
+
+type TemplateMethod struct {
+	Name              string
+	Request           string
+	Response          string
+	ClientStreaming   bool
+	ServerStreaming   bool
+	Description       string
+	Deprecated        bool
+	DeprecationReason string
+	Since             string
+}
+
+type TemplateCode struct {
+	Language string
+	Code     string
+}
+
+func NewTemplateGenerator(codegen *Codegenerator) *TemplateGenerator {
+	g := &TemplateGenerator{codegen: codegen, headings: DefaultHeadings()}
+	funcs := TemplateFuncs()
+	funcs["headings"] = func() Headings {
+		return g.headings
+	}
+	g.template = template.Must(template.New(DocumentTemplate).Funcs(funcs).Parse(DefaultTemplate))
+
+	return g
+}
+
+// Parse adds the template, its definitions replace the default template blocks with the same names
+func (g *TemplateGenerator) Parse(name, text string) error {
+	if _, err := g.template.New(name).Parse(text); err != nil {
+		return fmt.Errorf("invalid template: %s", err.Error())
+	}
+
+	return nil
+}
+
+// SetPrefix sets the markdown document placed before the generated one as is, its headings are available to the templates
+func (g *TemplateGenerator) SetPrefix(source string) error {
+	prefix, err := md.Parse(source)
+	if err != nil {
+		return err
+	}
+	g.prefix = prefix
+	g.prefixSource = source
+
+	return nil
+}
+
+func (g *TemplateGenerator) SetOptions(options TemplateOptions) {
+	g.options = options
+}
+
+// SetHeadings sets the heading texts available to the templates, empty ones keep their defaults
+func (g *TemplateGenerator) SetHeadings(headings Headings) {
+	g.headings = headingsOrDefaults(headings)
+}
+
+func (g *TemplateGenerator) Generate(parsedFiles []ParsedFile) (*md.Document, error) {
+	links := newLinkResolver(parsedFiles, func(ParsedFile) string {
+		return ""
+	})
+	var scalars []string
+	// the document template is looked up since templates redefining it replace the one the generator was created with
+	tmpl, err := g.template.Lookup(DocumentTemplate).Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute template: %s", err.Error())
+	}
+	tmpl.Funcs(template.FuncMap{
+		"link": func(text, fullName string) string {
+			url, scalar, ok := links.typeUrl(fullName, "")
+			if !ok {
+				log.Warn().Msgf("unresolved link '%s' to '#%s'", text, fullName)
+				return typeLink(text, fullName)
+			}
+			if scalar && arrayutils.Contains(fullName, scalars) == -1 {
+				scalars = append(scalars, fullName)
+			}
+			return "[" + escapeInline(text, true) + "](" + escapeUrl(url, true) + ")"
+		},
+	})
+
+	var builder strings.Builder
+	if err = tmpl.ExecuteTemplate(&builder, DocumentTemplate, g.data(parsedFiles)); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %s", err.Error())
+	}
+
+	result := &md.Document{}
+	if g.prefix != nil {
+		result.AddSection(prefixSection(g.prefixSource))
+	}
+	generated := md.NewSectionBuilder().Build()
+	generated.AddElement(md.NewRawBuilder().Text(builder.String()).Build())
+	result.AddSection(generated)
+	if len(scalars) > 0 {
+		result.AddSection(scalarSection(scalars, g.headings.ScalarTypes))
+	}
+
+	return result, nil
+}
+
+func (g *TemplateGenerator) data(parsedFiles []ParsedFile) *TemplateData {
+	sortedFiles := make([]ParsedFile, len(parsedFiles))
+	copy(sortedFiles, parsedFiles)
+	sort.SliceStable(sortedFiles, func(i, j int) bool {
+		return sortedFiles[i].index < sortedFiles[j].index
+	})
+	refs := buildReferenceIndex(parsedFiles)
+
+	data := &TemplateData{Headings: g.headings, Options: g.options}
+	if g.prefix != nil {
+		data.PrefixHeadings = templateHeadings(prefixHeadings(g.prefix), 0, nil)
+	}
+	for _, parsedFile := range sortedFiles {
+		file := TemplateFile{Name: parsedFile.filename, Title: parsedFile.title, Package: parsedFile.pkg}
+		entries := make([]Entry, len(parsedFile.entries))
+		copy(entries, parsedFile.entries)
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].index < entries[j].index
+		})
+		for _, entry := range entries {
+			switch {
+			case entry.t == EntryTypeMessage && entry.msg != nil && entry.msg.m != nil:
+				file.Messages = append(file.Messages, g.message(parsedFiles, entry.msg, refs))
+			case entry.t == EntryTypeEnum && entry.enum != nil && entry.enum.e != nil:
+				file.Enums = append(file.Enums, templateEnum(entry.enum, refs))
+			}
+		}
+		for _, service := range parsedFile.services {
+			file.Services = append(file.Services, templateService(service))
+		}
+		data.Files = append(data.Files, file)
+	}
+
+	allEntries, enums := collectEntries(parsedFiles)
+	messages := make(map[string]*TemplateMessage)
+	enumsByName := make(map[string]*TemplateEnum)
+	for _, file := range data.Files {
+		for _, message := range file.Messages {
+			messages[message.FullName] = message
+		}
+		for _, enum := range file.Enums {
+			enumsByName[enum.FullName] = enum
+		}
+	}
+	for _, entry := range allEntries {
+		if entry.t == EntryTypeMessage && entry.msg.m != nil {
+			data.Messages = append(data.Messages, messages[entry.msg.m.GetFullName()])
+		}
+	}
+	for _, entry := range enums {
+		if entry.enum.e != nil {
+			data.Enums = append(data.Enums, enumsByName[entry.enum.e.GetFullName()])
+		}
+	}
+
+	return data
+}
+
+func (g *TemplateGenerator) message(files []ParsedFile, message *Message, refs referenceIndex) *TemplateMessage {
+	result := &TemplateMessage{
+		Name:              message.m.GetName(),
+		FullName:          message.m.GetFullName(),
+		Anchor:            typeAnchor(message.m.GetFullName()),
+		Header:            message.header,
+		Description:       message.description,
+		Deprecated:        message.deprecated.Present(),
+		DeprecationReason: message.deprecated.OrElse(""),
+		Since:             message.since,
+		Response:          message.response,
+		UsedBy:            templateReferences(refs[message.m.GetFullName()]),
+	}
+	for _, field := range message.fields {
+		templateField := TemplateField{
+			Name:              field.d.GetName(),
+			Number:            field.d.GetNumber(),
+			Type:              strings.TrimPrefix(pbTypeToString(field.d), message.m.GetPackage()+"."),
+			TypeName:          protoTypeName(field.d),
+			Label:             pbLabel(field.d),
+			Description:       field.description,
+			Deprecated:        field.deprecated.Present(),
+			DeprecationReason: field.deprecated.OrElse(""),
+			Since:             field.since,
+		}
+		flags := field.flags.OrElse(FieldFlags{})
+		flags.min.IfPresent(func(min float64) {
+			templateField.Min = strconv.FormatFloat(min, 'f', -1, 64)
+		})
+		flags.max.IfPresent(func(max float64) {
+			templateField.Max = strconv.FormatFloat(max, 'f', -1, 64)
+		})
+		flags.maxLength.IfPresent(func(max int) {
+			templateField.MaxLength = strconv.Itoa(max)
+		})
+		templateField.Pattern = flags.pattern.OrElse("")
+		result.HasMin = result.HasMin || templateField.Min != ""
+		result.HasMax = result.HasMax || templateField.Max != ""
+		result.HasMaxLength = result.HasMaxLength || templateField.MaxLength != ""
+		result.HasPattern = result.HasPattern || templateField.Pattern != ""
+		result.HasSince = result.HasSince || templateField.Since != ""
+		result.Fields = append(result.Fields, templateField)
+	}
+	if message.code.Present() || message.autocode.Present() {
+		if code, err := g.codegen.Generate(files, message); err == nil {
+			result.Example = &TemplateCode{Language: code.GetLanguage(), Code: code.GetText()}
+		}
+	}
+
+	return result
+}
+
+func templateEnum(enum *Enum, refs referenceIndex) *TemplateEnum {
+	result := &TemplateEnum{
+		Name:              enum.e.GetName(),
+		FullName:          enum.e.GetFullName(),
+		Anchor:            typeAnchor(enum.e.GetFullName()),
+		Description:       enum.description,
+		Deprecated:        enum.deprecated.Present(),
+		DeprecationReason: enum.deprecated.OrElse(""),
+		Since:             enum.since,
+		UsedBy:            templateReferences(refs[enum.e.GetFullName()]),
+	}
+	for _, value := range enum.values {
+		result.Values = append(result.Values, TemplateEnumValue{
+			Name:              value.d.GetName(),
+			Number:            value.d.GetNumber(),
+			Description:       value.description,
+			Deprecated:        value.deprecated.Present(),
+			DeprecationReason: value.deprecated.OrElse(""),
+			Since:             value.since,
+		})
+		result.HasSince = result.HasSince || value.since != ""
+	}
+
+	return result
+}
+
+func templateService(service Service) TemplateService {
+	result := TemplateService{
+		Name:        service.s.GetName(),
+		FullName:    service.s.GetFullName(),
+		Description: service.description,
+	}
+	for _, method := range service.methods {
+		result.Methods = append(result.Methods, TemplateMethod{
+			Name:              method.d.GetName(),
+			Request:           strings.TrimPrefix(method.d.GetInputType(), "."),
+			Response:          strings.TrimPrefix(method.d.GetOutputType(), "."),
+			ClientStreaming:   method.d.GetClientStreaming(),
+			ServerStreaming:   method.d.GetServerStreaming(),
+			Description:       method.description,
+			Deprecated:        method.deprecated.Present(),
+			DeprecationReason: method.deprecated.OrElse(""),
+			Since:             method.since,
+		})
+	}
+
+	return result
+}
+
+func templateReferences(references []fieldReference) []TemplateReference {
+	var result []TemplateReference
+	for _, reference := range references {
+		result = append(result, TemplateReference{
+			Message:     reference.message.m.GetFullName(),
+			MessageName: reference.message.m.GetName(),
+			Field:       reference.field.d.GetName(),
+		})
+	}
+
+	return result
+}
+
+func templateHeadings(nodes []*headingNode, depth int, result []TemplateHeading) []TemplateHeading {
+	for _, node := range nodes {
+		result = append(result, TemplateHeading{Text: node.header.GetText(), Anchor: node.slug, Depth: depth})
+		result = templateHeadings(node.children, depth+1, result)
+	}
+
+	return result
+}
+
+/*
+TemplateFuncs returns the function library of the templates. Functions returning markdown escape their text arguments,
+row cells are expected to be markdown already.
+*/
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// link returns the link to the message, enum or scalar type, e.g. {{ link .Type .TypeName }}
+		"link": typeLink,
+		"anchor": func(fullName string) string {
+			return `<a name="` + escapeAnchor(typeAnchor(fullName)) + `"></a>`
+		},
+		"escape": func(text string) string {
+			return escapeInline(text, false)
+		},
+		"cell": func(text string) string {
+			return escapeInline(text, true)
+		},
+		"bold": func(text string) string {
+			return emphasize(text, "**")
+		},
+		"italic": func(text string) string {
+			return emphasize(text, "*")
+		},
+		"strike": func(text string) string {
+			return emphasize(text, md.EmphasisStrikethroughDelimiter)
+		},
+		"codespan": func(text string) string {
+			return escapeCodeSpan(text, true)
+		},
+		"code": func(language, code string) string {
+			fence := "```"
+			for strings.Contains(code, fence) {
+				fence += "`"
+			}
+			return fence + language + "\n" + strings.TrimSuffix(code, "\n") + "\n" + fence
+		},
+		// header returns the table header with the separator line, e.g. {{ header "Field" "Type" }}
+		"header": func(columns ...string) string {
+			separators := make([]string, len(columns))
+			for i := range columns {
+				separators[i] = "---"
+			}
+			return "| " + strings.Join(columns, " | ") + " |\n| " + strings.Join(separators, " | ") + " |"
+		},
+		// row returns the table row of the cells, which are markdown already, line breaks are replaced with <br>
+		"row": func(cells ...string) string {
+			for i, cell := range cells {
+				cells[i] = strings.ReplaceAll(cell, "\n", "<br>")
+			}
+			return "| " + strings.Join(cells, " | ") + " |"
+		},
+		"indent": func(depth int) string {
+			return strings.Repeat("  ", depth)
+		},
+		"deprecationNote": deprecationNote,
+		"join":            strings.Join,
+		// headings returns the heading texts set to the generator
+		"headings": DefaultHeadings,
+	}
+}
+
+// typeLink returns the unresolved link to the type, the generator replaces it with the resolving one
+func typeLink(text, fullName string) string {
+	return "[" + escapeInline(text, true) + "](#" + escapeUrl(fullName, true) + ")"
+}
+
+func emphasize(text, delimiter string) string {
+	if text == "" {
+		return ""
+	}
+
+	return delimiter + escapeInline(text, true) + delimiter
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func renderTemplate(t *testing.T, generator *TemplateGenerator, files []ParsedFile) string {
+	document, err := generator.Generate(files)
+	require.NoError(t, err)
+	content, err := RenderString(NewMarkdownRenderer(DefaultRenderConfig()), document)
+	require.NoError(t, err)
+
+	return content
+}
+
+func TestTemplateGenerator_default(t *testing.T) {
+	files := diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		common.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{
+			{Path: []int32{4, 0}, Span: []int32{1, 0, 10}, LeadingComments: proto.String(" Amount of money @since=1.2\n")},
+		}}
+	})
+	content := renderTemplate(t, NewTemplateGenerator(NewSeededCodegenerator(1)), files)
+
+	assert.Contains(t, content, "* [Money](#common-money)")
+	assert.Contains(t, content, "## API Description")
+	assert.Contains(t, content, `<a name="common-money"></a>`)
+	assert.Contains(t, content, "#### common.Money message description:")
+	assert.Contains(t, content, "**Since:** 1.2")
+	assert.Contains(t, content, "| Field ")
+	assert.Contains(t, content, "[Currency](#common-currency)")
+	assert.Contains(t, content, "[int64](#scalar-int64)")
+	assert.Contains(t, content, "**Used by:**")
+	assert.Contains(t, content, "* [Order.price](#shop-order)")
+	assert.Contains(t, content, "## Enums")
+	assert.Contains(t, content, "## Scalar Value Types")
+}
+
+func TestTemplateGenerator_Parse(t *testing.T) {
+	files := diffTestFiles(t, nil)
+
+	t.Run("block", func(t *testing.T) {
+		generator := NewTemplateGenerator(NewSeededCodegenerator(1))
+		generator.SetHeadings(Headings{APIDescription: "Reference"})
+		require.NoError(t, generator.Parse("house.tmpl", `{{ define "fields" }}
+{{ header "Name" "Type" }}
+{{- range .Fields }}
+{{ row (codespan .Name) (link .Type .TypeName) }}
+{{- end }}
+{{ end }}`))
+		content := renderTemplate(t, generator, files)
+		assert.Contains(t, content, "## Reference")
+		assert.Contains(t, content, "| Name ")
+		assert.Contains(t, content, "`amount`")
+		assert.NotContains(t, content, "| Label ")
+	})
+
+	t.Run("document", func(t *testing.T) {
+		generator := NewTemplateGenerator(NewSeededCodegenerator(1))
+		require.NoError(t, generator.Parse("list.tmpl", `{{ define "document" }}
+{{- range .Messages }}
+* {{ link .Name .FullName }} {{ len .Fields }}
+{{- end }}
+{{ end }}`))
+		content := renderTemplate(t, generator, files)
+		assert.Equal(t, 3, strings.Count(content, "* ["))
+		assert.Contains(t, content, "[Order](#shop-order) 2")
+		assert.NotContains(t, content, "## Enums")
+	})
+
+	t.Run("verbatim", func(t *testing.T) {
+		generator := NewTemplateGenerator(NewSeededCodegenerator(1))
+		require.NoError(t, generator.Parse("details.tmpl", `{{ define "document" }}
+{{- range .Messages -}}
+<details><summary>{{ .Name }}</summary>
+
+{{ range .Fields }}* {{ link .Name .TypeName }} _{{ .Number }}_
+{{ end }}
+</details>
+{{ end }}
+{{- end }}`))
+		content := renderTemplate(t, generator, files)
+		assert.True(t, strings.HasPrefix(content, "<details><summary>Money</summary>\n\n* [amount](#scalar-int64) _1_\n"))
+		assert.Contains(t, content, "* [item](#shop-item) _2_\n\n</details>\n")
+		assert.Contains(t, content, "## Scalar Value Types")
+		assert.Contains(t, content, `<a name="scalar-string"></a>`)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		generator := NewTemplateGenerator(NewSeededCodegenerator(1))
+		assert.Error(t, generator.Parse("broken.tmpl", `{{ define "fields" }}{{ range .Fields }}{{ end }}`))
+	})
+
+	t.Run("execution error", func(t *testing.T) {
+		generator := NewTemplateGenerator(NewSeededCodegenerator(1))
+		require.NoError(t, generator.Parse("missing.tmpl", `{{ define "message" }}{{ .Unknown }}{{ end }}`))
+		_, err := generator.Generate(files)
+		assert.Error(t, err)
+	})
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	funcs := TemplateFuncs()
+	assert.Equal(t, "[\\_a](#shop.Order)", funcs["link"].(func(string, string) string)("_a", "shop.Order"))
+	assert.Equal(t, "**x\\|y**", funcs["bold"].(func(string) string)("x|y"))
+	assert.Equal(t, "| a | b<br>c |", funcs["row"].(func(...string) string)("a", "b\nc"))
+	assert.Equal(t, "| A | B |\n| --- | --- |", funcs["header"].(func(...string) string)("A", "B"))
+	assert.Equal(t, "````json\n```\n````", funcs["code"].(func(string, string) string)("json", "```"))
+}
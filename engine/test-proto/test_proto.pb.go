@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.27.1
+// 	protoc-gen-go v1.31.0
 // 	protoc        v4.24.3
 // source: test_proto
 
@@ -276,7 +276,6 @@ type ClientRequest struct {
 
 	Trx string `protobuf:"bytes,1,opt,name=trx,proto3" json:"trx,omitempty"` // unique transaction id of each message to match it with server response.
 	// Types that are assignable to Action:
-	//
 	//	*ClientRequest_TokenRequest
 	//	*ClientRequest_RegistrationRequest
 	Action isClientRequest_Action `protobuf_oneof:"action"`
@@ -367,7 +366,6 @@ type ServerResponse struct {
 
 	Trx string `protobuf:"bytes,1,opt,name=trx,proto3" json:"trx,omitempty"`
 	// Types that are assignable to Action:
-	//
 	//	*ServerResponse_TokenResponse
 	//	*ServerResponse_LoginRequest
 	Action isServerResponse_Action `protobuf_oneof:"action"`
@@ -1211,7 +1209,25 @@ var file_test_proto_rawDesc = []byte{
 	0x13, 0x0a, 0x0f, 0x4c, 0x53, 0x53, 0x5f, 0x42, 0x41, 0x44, 0x5f, 0x50, 0x41, 0x59, 0x4c, 0x4f,
 	0x41, 0x44, 0x10, 0x02, 0x12, 0x1c, 0x0a, 0x18, 0x4c, 0x53, 0x53, 0x5f, 0x53, 0x45, 0x4c, 0x4c,
 	0x45, 0x52, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45,
-	0x10, 0x03, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x10, 0x03, 0x32, 0x9c, 0x02, 0x0a, 0x0b, 0x41, 0x75, 0x74, 0x68, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x12, 0x4c, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x20, 0x2e, 0x64, 0x6f,
+	0x63, 0x5f, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x74, 0x65, 0x73, 0x74,
+	0x2e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e,
+	0x64, 0x6f, 0x63, 0x5f, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x74, 0x65,
+	0x73, 0x74, 0x2e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x5d, 0x0a, 0x08, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x27, 0x2e, 0x64,
+	0x6f, 0x63, 0x5f, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x74, 0x65, 0x73,
+	0x74, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x64, 0x6f, 0x63, 0x5f, 0x67, 0x65, 0x6e, 0x65,
+	0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x60, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x73, 0x12, 0x26,
+	0x2e, 0x64, 0x6f, 0x63, 0x5f, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x74,
+	0x65, 0x73, 0x74, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x27, 0x2e, 0x64, 0x6f, 0x63, 0x5f, 0x67, 0x65, 0x6e,
+	0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30,
+	0x01, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1253,8 +1269,14 @@ var file_test_proto_depIdxs = []int32{
 	1,  // 5: doc_generator_test.RegistrationResponse.status:type_name -> doc_generator_test.RegistrationStatus
 	3,  // 6: doc_generator_test.ListServersResponse.status:type_name -> doc_generator_test.ListServersStatus
 	12, // 7: doc_generator_test.ListServersResponse.servers:type_name -> doc_generator_test.ServerEntry
-	8,  // [8:8] is the sub-list for method output_type
-	8,  // [8:8] is the sub-list for method input_type
+	6,  // 8: doc_generator_test.AuthService.Token:input_type -> doc_generator_test.TokenRequest
+	8,  // 9: doc_generator_test.AuthService.Register:input_type -> doc_generator_test.RegistrationRequest
+	10, // 10: doc_generator_test.AuthService.ListServers:input_type -> doc_generator_test.ListServersRequest
+	7,  // 11: doc_generator_test.AuthService.Token:output_type -> doc_generator_test.TokenResponse
+	9,  // 12: doc_generator_test.AuthService.Register:output_type -> doc_generator_test.RegistrationResponse
+	11, // 13: doc_generator_test.AuthService.ListServers:output_type -> doc_generator_test.ListServersResponse
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
 	8,  // [8:8] is the sub-list for extension type_name
 	8,  // [8:8] is the sub-list for extension extendee
 	0,  // [0:8] is the sub-list for field type_name
@@ -1403,7 +1425,7 @@ func file_test_proto_init() {
 			NumEnums:      4,
 			NumMessages:   10,
 			NumExtensions: 0,
-			NumServices:   0,
+			NumServices:   1,
 		},
 		GoTypes:           file_test_proto_goTypes,
 		DependencyIndexes: file_test_proto_depIdxs,
@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"strings"
+
 	"github.com/kordax/pb-md5-generator/engine/md"
 )
 
@@ -67,23 +69,27 @@ func MkRow() *md.Row {
 	return md.NewRowBuilder().Build()
 }
 
-func MkFieldTypeLink(field *MessageField) *md.Link {
+// MkFieldTypeLink returns type reference link, the type name is shown relative to the package
+func MkFieldTypeLink(field *MessageField, pkg string) *md.Link {
 	tStr := pbTypeToString(field.d)
-	link := "#" + tStr
-	return md.NewLinkBuilder().Text(tStr).Url(link).Build()
+	link := "#" + protoTypeName(field.d)
+	text := tStr
+	if pkg != "" {
+		text = strings.TrimPrefix(tStr, pkg+".")
+	}
+	return md.NewLinkBuilder().Text(text).Url(link).Build()
 }
 
+// MkLink returns type reference link, which is resolved to the type anchor by the link resolution pass
 func MkLink(name, fullName string) *md.Link {
 	link := "#" + fullName
 	return md.NewLinkBuilder().Text(name).Url(link).Build()
 }
 
 func MkEnumRef(enum *Enum) *md.HtmlRef {
-	link := MkLink(enum.e.GetName(), enum.e.GetFullName())
-	return md.NewHtmlRefBuilder().Name(link.GetUrl()[1:]).Build()
+	return md.NewHtmlRefBuilder().Name(typeAnchor(enum.e.GetFullName())).Build()
 }
 
 func MkMessageRef(msg *Message) *md.HtmlRef {
-	link := MkLink(msg.m.GetName(), msg.m.GetFullName())
-	return md.NewHtmlRefBuilder().Name(link.GetUrl()[1:]).Build()
+	return md.NewHtmlRefBuilder().Name(typeAnchor(msg.m.GetFullName())).Build()
 }
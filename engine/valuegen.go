@@ -0,0 +1,280 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/goombaio/namegenerator"
+	"github.com/kordax/pb-md5-generator/engine/password"
+	"github.com/pseudomuto/protokit"
+	"gitlab.com/kordax/basic-utils/opt"
+)
+
+/*
+ValueGenerator generates example value of a single message field for @autocode.
+Generators are registered in Codegenerator per value type, which is either detected from the field or set with @type.
+*/
+type ValueGenerator interface {
+	Generate(field *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error)
+}
+
+type ValueGeneratorFunc func(field *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error)
+
+func (f ValueGeneratorFunc) Generate(field *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	return f(field, flags, r)
+}
+
+var passGen = password.NewGenerator(1, 7, 5, 1)
+
+// JWTExampleKey is the HS256 key generated JWT examples are signed with.
+const JWTExampleKey = "pb-md5-generator-example-key"
+
+// builtinValueTypes are the types served by every Codegenerator, including the enum and jwt generators bound to it
+func builtinValueTypes() []ValueType {
+	types := []ValueType{ValueTypeEnum, ValueTypeJWT}
+	for t := range builtinValueGenerators() {
+		types = append(types, t)
+	}
+
+	return types
+}
+
+func builtinValueGenerators() map[ValueType]ValueGenerator {
+	return map[ValueType]ValueGenerator{
+		ValueTypeInt:      ValueGeneratorFunc(generateInt),
+		ValueTypeUInt:     ValueGeneratorFunc(generateUInt),
+		ValueTypeFloat:    ValueGeneratorFunc(generateFloat),
+		ValueTypeBool:     ValueGeneratorFunc(generateBool),
+		ValueTypeString:   ValueGeneratorFunc(generateString),
+		ValueTypeEmail:    ValueGeneratorFunc(generateEmail),
+		ValueTypePhone:    ValueGeneratorFunc(generatePhone),
+		ValueTypePassword: ValueGeneratorFunc(generatePassword),
+		ValueTypeUUID:     ValueGeneratorFunc(generateUUID),
+	}
+}
+
+func generateInt(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return strconv.ParseInt(*value.Get(), 10, 64)
+	}
+
+	return int64WithinRange(r, floatToInt64(flags.GetMin().OrElse(0)), floatToInt64(flags.GetMax().OrElse(1000000))), nil
+}
+
+func generateUInt(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return strconv.ParseUint(*value.Get(), 10, 64)
+	}
+
+	return uint64WithinRange(r, floatToUint64(flags.GetMin().OrElse(0)), floatToUint64(flags.GetMax().OrElse(1000000))), nil
+}
+
+func generateFloat(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return strconv.ParseFloat(*value.Get(), 64)
+	}
+	minVal := flags.GetMin().OrElse(0.0)
+
+	return minVal + r.Float64()*(flags.GetMax().OrElse(1000000)-minVal), nil
+}
+
+func generateBool(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return strconv.ParseBool(*value.Get())
+	}
+
+	return r.Intn(2) == 1, nil
+}
+
+func generateString(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return *value.Get(), nil
+	}
+	if pattern := flags.GetPattern(); pattern.Present() {
+		return generatePattern(*pattern.Get(), flags.GetMaxLength(), r)
+	}
+
+	return truncate(randomName(r), flags.GetMaxLength()), nil
+}
+
+func generateEmail(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return *value.Get(), nil
+	}
+
+	return truncate(randomName(r)+"@email.com", flags.GetMaxLength()), nil
+}
+
+func generatePhone(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return *value.Get(), nil
+	}
+	//+NNN.NNNNNNNNNN
+	phone := "+" + strconv.Itoa(int(int64WithinRange(r, 0, 1010)))
+	phone += "." + strconv.Itoa(int(int64WithinRange(r, 1000000000, 9999999999)))
+
+	return phone, nil
+}
+
+func generatePassword(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return *value.Get(), nil
+	}
+
+	return passGen.Password(r), nil
+}
+
+func generateUUID(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return *value.Get(), nil
+	}
+	id, err := uuid.NewRandomFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return id.String(), nil
+}
+
+/*
+generateJWT generates HS256 signed token with sub, iat and exp (iat + 1h) claims, which can be overridden or extended with @claim.
+Time claims (iat, exp, nbf) accept either unix time or offset from now, e.g. @claim=exp:+24h. Now is the clock of the generator.
+*/
+func (g *Codegenerator) generateJWT(_ *protokit.FieldDescriptor, flags FieldFlags, r *rand.Rand) (any, error) {
+	if value := flags.GetValue(); value.Present() {
+		return *value.Get(), nil
+	}
+
+	now := g.now().Unix()
+	claims := map[string]any{
+		"sub": randomName(r),
+		"iat": now,
+		"exp": now + int64(time.Hour.Seconds()),
+	}
+	for _, claim := range flags.GetClaims() {
+		value, err := parseClaimValue(claim.Left, claim.Right, now)
+		if err != nil {
+			return nil, err
+		}
+		claims[claim.Left] = value
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(JWTExampleKey))
+	mac.Write([]byte(token))
+
+	return token + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func parseClaimValue(name, value string, now int64) (any, error) {
+	switch name {
+	case "iat", "exp", "nbf":
+		if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+			offset, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid '%s' claim offset: %s", name, err.Error())
+			}
+			return now + int64(offset.Seconds()), nil
+		}
+		return strconv.ParseInt(value, 10, 64)
+	}
+
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f, nil
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b, nil
+	}
+
+	return strings.Trim(value, "\"'"), nil
+}
+
+func randomName(r *rand.Rand) string {
+	adjective := namegenerator.ADJECTIVES[r.Intn(len(namegenerator.ADJECTIVES))]
+	noun := namegenerator.NOUNS[r.Intn(len(namegenerator.NOUNS))]
+
+	return fmt.Sprintf("%v-%v", adjective, noun)
+}
+
+func truncate(str string, maxLen opt.Opt[int]) string {
+	if maxLen.Present() && utf8.RuneCountInString(str) > *maxLen.Get() {
+		return string([]rune(str)[:*maxLen.Get()])
+	}
+
+	return str
+}
+
+func int64WithinRange(r *rand.Rand, min, max int64) int64 {
+	if max <= min {
+		return min
+	}
+
+	// the span is computed in uint64, since it overflows int64 for ranges wider than math.MaxInt64
+	return min + int64(uint64WithinRange(r, 0, uint64(max)-uint64(min)))
+}
+
+func uint64WithinRange(r *rand.Rand, min, max uint64) uint64 {
+	if max <= min {
+		return min
+	}
+	span := max - min
+	switch {
+	case span == math.MaxUint64:
+		return r.Uint64()
+	case span < math.MaxInt64:
+		return min + uint64(r.Int63n(int64(span+1)))
+	}
+
+	// Int63n can't take spans of 2^63 and above, values out of the span are rejected with probability below 1/2
+	for {
+		if value := r.Uint64(); value <= span {
+			return min + value
+		}
+	}
+}
+
+// floatToInt64 converts the @min/@max value, which is clamped to the int64 range
+func floatToInt64(f float64) int64 {
+	switch {
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	}
+
+	return int64(f)
+}
+
+// floatToUint64 converts the @min/@max value, which is clamped to the uint64 range
+func floatToUint64(f float64) uint64 {
+	switch {
+	case f >= math.MaxUint64:
+		return math.MaxUint64
+	case f <= 0:
+		return 0
+	}
+
+	return uint64(f)
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"github.com/rs/zerolog/log"
+	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
+)
+
+/*
+FilterAudience returns the files documented for the audience, i.e. without files, messages, fields, enums and enum values,
+which visibility doesn't include it. The parsed files are not modified. Visible fields, which refer to the hidden types,
+are reported as warnings.
+*/
+func FilterAudience(parsedFiles []ParsedFile, audience string) []ParsedFile {
+	hidden := make(map[string]bool)
+	result := make([]ParsedFile, 0, len(parsedFiles))
+	for _, file := range parsedFiles {
+		if !visibleTo(file.visibility, audience) {
+			log.Info().Msgf("hiding file '%s' from audience '%s'", file.filename, audience)
+			hideEntries(file.entries, hidden)
+			continue
+		}
+		file.entries = entriesFor(file.entries, audience, hidden)
+		result = append(result, file)
+	}
+
+	for _, ref := range hiddenReferences(result, hidden) {
+		log.Warn().Msgf("field '%s' visible to audience '%s' refers to hidden type '%s'", ref.field.d.GetFullName(), audience, trimTypeName(ref.field.d.GetTypeName()))
+	}
+
+	return result
+}
+
+func entriesFor(entries []Entry, audience string, hidden map[string]bool) []Entry {
+	result := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		switch {
+		case entry.t == EntryTypeMessage && entry.msg != nil:
+			if !visibleTo(entry.msg.visibility, audience) {
+				hideEntries([]Entry{entry}, hidden)
+				continue
+			}
+			msg := *entry.msg
+			msg.fields = arrayutils.Filter(entry.msg.fields, func(v *MessageField) bool {
+				return visibleTo(v.visibility, audience)
+			})
+			msg.entries = entriesFor(entry.msg.entries, audience, hidden)
+			entry.msg = &msg
+		case entry.t == EntryTypeEnum && entry.enum != nil:
+			if !visibleTo(entry.enum.visibility, audience) {
+				hideEntries([]Entry{entry}, hidden)
+				continue
+			}
+			enum := *entry.enum
+			enum.values = arrayutils.Filter(entry.enum.values, func(v *EnumField) bool {
+				return visibleTo(v.visibility, audience)
+			})
+			entry.enum = &enum
+		}
+		result = append(result, entry)
+	}
+
+	return result
+}
+
+// hideEntries adds full names of the messages, including nested ones, and enums to the hidden types
+func hideEntries(entries []Entry, hidden map[string]bool) {
+	for _, entry := range entries {
+		switch {
+		case entry.t == EntryTypeMessage && entry.msg != nil && entry.msg.m != nil:
+			hidden[entry.msg.m.GetFullName()] = true
+			hideEntries(entry.msg.entries, hidden)
+		case entry.t == EntryTypeEnum && entry.enum != nil && entry.enum.e != nil:
+			hidden[entry.enum.e.GetFullName()] = true
+		}
+	}
+}
+
+// hiddenReferences returns the fields of the documented messages, which refer to the hidden types
+func hiddenReferences(parsedFiles []ParsedFile, hidden map[string]bool) []fieldReference {
+	refs := buildReferenceIndex(parsedFiles)
+	var result []fieldReference
+	for _, name := range unionKeys(refs, nil) {
+		if hidden[name] {
+			result = append(result, refs[name]...)
+		}
+	}
+
+	return result
+}
+
+func visibleTo(visibility []string, audience string) bool {
+	return len(visibility) == 0 || arrayutils.Contains(audience, visibility) != -1
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func visibilityTestFiles(t *testing.T, commonPackage string) []ParsedFile {
+	comment := func(text string, path ...int32) *descriptorpb.SourceCodeInfo_Location {
+		return &descriptorpb.SourceCodeInfo_Location{Path: path, Span: []int32{1, 0, 10}, LeadingComments: proto.String(" " + text + "\n")}
+	}
+
+	return diffTestFiles(t, func(common, orders *descriptorpb.FileDescriptorProto) {
+		common.EnumType[0].Value = append(common.EnumType[0].Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String("EUR"), Number: proto.Int32(1)})
+		common.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{
+			comment(commonPackage, 2),
+			comment("@internal", 4, 0, 2, 1),
+			comment("@visibility=partner,internal", 5, 0, 2, 1),
+		}}
+		orders.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{comment("Order item @internal", 4, 1)}}
+	})
+}
+
+func TestFilterAudience(t *testing.T) {
+	files := visibilityTestFiles(t, "Common types")
+
+	t.Run("partner", func(t *testing.T) {
+		filtered := FilterAudience(files, "partner")
+		api := newApiIndex(filtered)
+		assert.NotContains(t, api.messages, "shop.Item")
+		assert.Len(t, api.messages["common.Money"].fields, 1)
+		assert.Len(t, api.enums["common.Currency"].values, 2)
+		assert.Len(t, newApiIndex(files).messages["common.Money"].fields, 2)
+	})
+
+	t.Run("public", func(t *testing.T) {
+		api := newApiIndex(FilterAudience(files, "public"))
+		assert.Len(t, api.enums["common.Currency"].values, 1)
+	})
+
+	t.Run("internal", func(t *testing.T) {
+		api := newApiIndex(FilterAudience(files, InternalMarker))
+		assert.Len(t, api.messages, 3)
+		assert.Len(t, api.messages["common.Money"].fields, 2)
+	})
+
+	t.Run("internal file", func(t *testing.T) {
+		filtered := FilterAudience(visibilityTestFiles(t, "@visibility=internal"), "partner")
+		assert.Len(t, filtered, 1)
+		assert.Equal(t, "api/orders.proto", filtered[0].Filename())
+	})
+}
+
+func TestHiddenReferences(t *testing.T) {
+	files := visibilityTestFiles(t, "")
+	refs := hiddenReferences(files, map[string]bool{"shop.Item": true, "common.Currency": true})
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, ref.field.d.GetFullName())
+	}
+	assert.Equal(t, []string{"common.Money.currency", "shop.Order.item"}, names)
+}
+
+func TestParseVisibility(t *testing.T) {
+	assert.Nil(t, parseVisibility([]string{"since=1.0"}))
+	assert.Equal(t, []string{"internal"}, parseVisibility([]string{"internal"}))
+	assert.Equal(t, []string{"partner", "internal"}, parseVisibility([]string{"visibility=partner,internal"}))
+}
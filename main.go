@@ -12,6 +12,7 @@ import (
 
 	plugingo "github.com/golang/protobuf/protoc-gen-go/plugin"
 	"github.com/kordax/pb-md5-generator/engine"
+	"github.com/kordax/pb-md5-generator/engine/md"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	arrayutils "gitlab.com/kordax/basic-utils/array-utils"
@@ -20,32 +21,111 @@ import (
 )
 
 const pbDescName = "protobuf.desc"
+const diffCommand = "diff"
+const breakingCommand = "breaking"
 
+var configFile = flag.String("config", "", "pipeline configuration file, "+engine.DefaultPipelineConfigFile+" is used if it exists, flags override its settings")
 var dir = flag.String("d", "", ".proto files directory, e.g.: ./test/test-protos")
 var file = flag.String("f", "", "force specific files, e.g.: ./test/my-proto.proto;./test/my-next-proto.proto")
+var importPaths = flag.String("I", "", "additional import paths of the .proto files, e.g.: ./third_party;./vendor/protos")
 var pbOutput = flag.String("pbo", "doc-generator-tmp", "temporary protobuf output directory location")
 var output = flag.String("o", "./doc-generator-output", "markdown output file")
 var prefix = flag.String("p", "", "prefix markdown document file that will be added to the beginning of the resulting .md file")
+var templateFile = flag.String("template", "", "text/template file, which {{define}} blocks replace the ones of the default layout, 'default' uses the default layout")
+var splice = flag.Bool("splice", false, "replace only the content between <!-- pbmd:begin [region] --> and <!-- pbmd:end [region] --> markers of the existing output file")
+var split = flag.String("split", "", "split markdown output into one page per proto 'file' or 'package' plus index.md, the output is a directory then")
+var diagrams = flag.String("diagrams", "", "add mermaid class diagrams of the messages per proto 'file' or per @header group ('header')")
+var format = flag.String("format", string(engine.OutputFormatMarkdown), "output format: md, openapi, asyncapi, dot (openapi and asyncapi are written to .yaml or .json output file, dot to .dot file)")
+var apiVersion = flag.String("api-version", "1.0.0", "API version written to exported OpenAPI/AsyncAPI documents")
+var channel = flag.String("channel", "/", "AsyncAPI channel name that message envelopes are sent over")
+var serverUrl = flag.String("server-url", "", "AsyncAPI server url, e.g.: wss://api.example.com/ws")
+var dotCluster = flag.String("dot-cluster", "package", "DOT graph clustering of the types: package, file")
+var dotRoot = flag.String("dot-root", "", "full name of the message, enum or service the DOT graph starts from, e.g.: api.v1.User")
+var dotDepth = flag.Int("dot-depth", 0, "max number of edges from the DOT graph root, 0 means unlimited")
+var include = flag.String("include", "", "file glob patterns of the documented files, e.g.: billing/**;api/*.proto")
+var exclude = flag.String("exclude", "", "file glob patterns of the files excluded from the documents, e.g.: **/internal/**")
+var includeNames = flag.String("include-names", "", "regular expressions of documented message, enum and service full names, e.g.: billing\\.v1\\..*")
+var excludeNames = flag.String("exclude-names", "", "regular expressions of message, enum and service full names excluded from the documents")
+var audience = flag.String("audience", "", "document the API for the audience, e.g. partner, anything which @visibility or @internal doesn't include it is hidden")
+var asOfVersion = flag.String("as-of-version", "", "document the API as of the version, anything with a newer @since version is hidden")
+var tocSinceBadges = flag.Bool("toc-since-badges", false, "add 'new in <version>' badges of @since versions to the table of contents")
+var tocHideDeprecated = flag.Bool("toc-hide-deprecated", false, "hide deprecated messages and enums from the table of contents")
+var autocodeDeprecated = flag.Bool("autocode-deprecated", false, "keep deprecated fields and enum values in @autocode examples")
+var seed = flag.Int64("seed", 0, "seed of the @autocode examples to make them reproducible, 0 means a random seed")
+var dotHideWellKnown = flag.Bool("dot-hide-wkt", false, "hide google.protobuf well-known types in the DOT graph")
+var renderEmphasis = flag.String("render-emphasis", "", "markdown emphasis syntax: asterisks, underscores")
+var renderHeader = flag.String("render-header", "", "markdown header syntax: number_signs, underlined")
+var renderRule = flag.String("render-rule", "", "markdown horizontal rule syntax: asterisks, underscores, dashes")
+var renderCodeblock = flag.String("render-codeblock", "", "markdown code block syntax: backticks, tildes")
+var renderList = flag.String("render-list", "", "markdown list syntax: asterisk, dash, plus")
+
+// pipelineFlags override the pipeline settings, outputFlags override the settings of every output
+var pipelineFlags = map[string]func(config *engine.PipelineConfig){
+	"d":                   func(config *engine.PipelineConfig) { config.Inputs.Dir = *dir },
+	"f":                   func(config *engine.PipelineConfig) { config.Inputs.Files = splitPatterns(*file) },
+	"I":                   func(config *engine.PipelineConfig) { config.Inputs.ImportPaths = splitPatterns(*importPaths) },
+	"pbo":                 func(config *engine.PipelineConfig) { config.Inputs.TmpDir = *pbOutput },
+	"include":             func(config *engine.PipelineConfig) { config.Filters.Include = splitPatterns(*include) },
+	"exclude":             func(config *engine.PipelineConfig) { config.Filters.Exclude = splitPatterns(*exclude) },
+	"include-names":       func(config *engine.PipelineConfig) { config.Filters.IncludeNames = splitPatterns(*includeNames) },
+	"exclude-names":       func(config *engine.PipelineConfig) { config.Filters.ExcludeNames = splitPatterns(*excludeNames) },
+	"autocode-deprecated": func(config *engine.PipelineConfig) { config.Autocode.IncludeDeprecated = *autocodeDeprecated },
+	"seed":                func(config *engine.PipelineConfig) { config.Autocode.Seed = *seed },
+	"render-emphasis":     func(config *engine.PipelineConfig) { config.Render.Emphasis = *renderEmphasis },
+	"render-header":       func(config *engine.PipelineConfig) { config.Render.Header = *renderHeader },
+	"render-rule":         func(config *engine.PipelineConfig) { config.Render.Rule = *renderRule },
+	"render-codeblock":    func(config *engine.PipelineConfig) { config.Render.Codeblock = *renderCodeblock },
+	"render-list":         func(config *engine.PipelineConfig) { config.Render.List = *renderList },
+}
+
+var outputFlags = map[string]func(output *engine.OutputConfig){
+	"o":                   func(target *engine.OutputConfig) { target.Path = *output },
+	"format":              func(target *engine.OutputConfig) { target.Format = engine.OutputFormat(*format) },
+	"p":                   func(target *engine.OutputConfig) { target.Prefix = *prefix },
+	"template":            func(target *engine.OutputConfig) { target.Template = *templateFile },
+	"splice":              func(target *engine.OutputConfig) { target.Splice = *splice },
+	"split":               func(target *engine.OutputConfig) { target.Split = *split },
+	"diagrams":            func(target *engine.OutputConfig) { target.Diagrams = *diagrams },
+	"audience":            func(target *engine.OutputConfig) { target.Audience = *audience },
+	"as-of-version":       func(target *engine.OutputConfig) { target.AsOfVersion = *asOfVersion },
+	"toc-since-badges":    func(target *engine.OutputConfig) { target.TOC.SinceBadges = *tocSinceBadges },
+	"toc-hide-deprecated": func(target *engine.OutputConfig) { target.TOC.HideDeprecated = *tocHideDeprecated },
+	"api-version":         func(target *engine.OutputConfig) { target.API.Version = *apiVersion },
+	"channel":             func(target *engine.OutputConfig) { target.API.Channel = *channel },
+	"server-url":          func(target *engine.OutputConfig) { target.API.ServerUrl = *serverUrl },
+	"dot-cluster":         func(target *engine.OutputConfig) { target.Dot.Cluster = *dotCluster },
+	"dot-root":            func(target *engine.OutputConfig) { target.Dot.Root = *dotRoot },
+	"dot-depth":           func(target *engine.OutputConfig) { target.Dot.Depth = *dotDepth },
+	"dot-hide-wkt":        func(target *engine.OutputConfig) { target.Dot.HideWellKnown = *dotHideWellKnown },
+}
 
 func main() {
-	flag.Parse()
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: "02/01 15:04:05"})
 	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	if len(os.Args) > 1 && os.Args[1] == diffCommand {
+		runDiff(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == breakingCommand {
+		runBreaking(os.Args[2:])
+		return
+	}
+	flag.Parse()
 
-	checkDependencies()
-
-	if *dir == "" {
-		log.Error().Msg("empty proto files directory/string specified")
+	config, err := loadPipelineConfig()
+	if err != nil {
+		log.Err(err).Msg("invalid configuration")
 		os.Exit(1)
 	}
 
-	var files []string
-	if *file != "" {
-		files = strings.Split(*file, ";")
-	} else {
-		var err error
-		*dir = path.Clean(*dir)
+	checkDependencies()
+
+	*dir = path.Clean(config.Inputs.Dir)
+	*pbOutput = path.Clean(config.Inputs.TmpDir)
+	*importPaths = strings.Join(config.Inputs.ImportPaths, ";")
+	files := config.Inputs.Files
+	if len(files) == 0 {
 		files, err = getProtoFilesRecursively(*dir)
 		if err != nil {
 			log.Err(err).Msg("failed to list .proto files")
@@ -57,21 +137,17 @@ func main() {
 		os.Exit(1)
 	}
 
-	*output = path.Clean(*output)
-	*pbOutput = path.Clean(*pbOutput)
-
-	if !strings.HasSuffix(*output, ".md") {
-		*output = *output + ".md"
-	}
-
-	if *prefix != "" {
-		if stat, err := os.Stat(*prefix); err != nil {
-			log.Err(err).Msgf("prefix document '%s' doesn't exist", *prefix)
-			os.Exit(3)
-		} else {
-			if stat.IsDir() {
-				log.Err(err).Msgf("prefix document '%s' path is a directory", *prefix)
-				os.Exit(4)
+	for i := range config.Outputs {
+		config.Outputs[i].Path = outputPath(config.Outputs[i])
+		if config.Outputs[i].Prefix != "" {
+			if stat, err := os.Stat(config.Outputs[i].Prefix); err != nil {
+				log.Err(err).Msgf("prefix document '%s' doesn't exist", config.Outputs[i].Prefix)
+				os.Exit(3)
+			} else {
+				if stat.IsDir() {
+					log.Err(err).Msgf("prefix document '%s' path is a directory", config.Outputs[i].Prefix)
+					os.Exit(4)
+				}
 			}
 		}
 	}
@@ -83,7 +159,7 @@ func main() {
 		}
 	}
 
-	err := os.MkdirAll(*pbOutput, os.ModePerm)
+	err = os.MkdirAll(*pbOutput, os.ModePerm)
 	if err != nil {
 		log.Err(err).Msgf("failed to initialize output directory: %s", *pbOutput)
 		os.Exit(6)
@@ -100,24 +176,181 @@ func main() {
 		log.Err(err).Msg("failed to generate protobuf request from files")
 		os.Exit(7)
 	}
-
-	contentBytes, err := os.ReadFile(*prefix)
+	entries, err := engine.NewDescriptorParser(request).Parse()
+	if err == nil {
+		entries, err = engine.Filter(entries, config.FilterOptions())
+	}
 	if err != nil {
-		log.Err(err).Msgf("failed to read prefix markdown document: %s", *prefix)
-		os.Exit(8)
+		log.Err(err).Msg("[parser error] failed to parse protobuf request")
+		os.Exit(7)
+	}
+
+	for _, output := range config.Outputs {
+		writeOutput(config, output, entries)
+	}
+}
+
+/*
+loadPipelineConfig reads the -config file or pbmd.yaml of the working directory and overrides its settings with the flags set
+explicitly. Without the configuration file all flags are applied to a single output.
+*/
+func loadPipelineConfig() (*engine.PipelineConfig, error) {
+	config := &engine.PipelineConfig{}
+	visit := flag.VisitAll
+	filename := *configFile
+	if filename == "" {
+		if _, err := os.Stat(engine.DefaultPipelineConfigFile); err == nil {
+			filename = engine.DefaultPipelineConfigFile
+		}
+	}
+	if filename != "" {
+		log.Info().Msgf("reading configuration: %s", filename)
+		content, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer content.Close()
+		if config, err = engine.LoadPipelineConfig(content); err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err.Error())
+		}
+		visit = flag.Visit
+	}
+
+	outputSet := false
+	if len(config.Outputs) == 0 {
+		config.Outputs = []engine.OutputConfig{{}}
+	}
+	visit(func(f *flag.Flag) {
+		if override, ok := pipelineFlags[f.Name]; ok {
+			override(config)
+		}
+		if override, ok := outputFlags[f.Name]; ok {
+			for i := range config.Outputs {
+				override(&config.Outputs[i])
+			}
+			outputSet = outputSet || f.Name == "o"
+		}
+	})
+	if filename != "" && outputSet && len(config.Outputs) > 1 {
+		return nil, fmt.Errorf("-o flag can't override %d outputs of %s", len(config.Outputs), filename)
+	}
+
+	config.ApplyDefaults()
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// outputPath adds the extension of the output format to the output file path
+func outputPath(output engine.OutputConfig) string {
+	result := path.Clean(output.Path)
+	switch output.Format {
+	case engine.OutputFormatMarkdown:
+		if !strings.HasSuffix(result, ".md") && output.Split == "" {
+			result = result + ".md"
+		}
+	case engine.OutputFormatOpenAPI, engine.OutputFormatAsyncAPI:
+		switch path.Ext(result) {
+		case ".json", ".yaml", ".yml":
+		default:
+			result = result + ".yaml"
+		}
+	case engine.OutputFormatDot:
+		if path.Ext(result) != ".dot" {
+			result = result + ".dot"
+		}
+	}
+
+	return result
+}
+
+// writeOutput generates and writes the document of the output, it exits on errors
+func writeOutput(config *engine.PipelineConfig, output engine.OutputConfig, entries []engine.ParsedFile) {
+	// configuration is validated already
+	renderConfig, _ := config.Render.Config()
+	splitMode, _ := engine.ParseSplitMode(output.Split)
+	entries = outputEntries(config, output, entries)
+
+	var prefixSource string
+	if output.Format == engine.OutputFormatMarkdown && output.Prefix != "" {
+		contentBytes, err := os.ReadFile(output.Prefix)
+		if err != nil {
+			log.Err(err).Msgf("failed to read prefix markdown document: %s", output.Prefix)
+			os.Exit(8)
+		}
+		prefixSource = string(contentBytes)
+	}
+
+	var content string
+	var err error
+	switch {
+	case output.Format == engine.OutputFormatDot:
+		content, err = generateDot(output, entries)
+	case output.Format == engine.OutputFormatOpenAPI || output.Format == engine.OutputFormatAsyncAPI:
+		content, err = generateAPI(config, output, entries)
+	case output.Splice:
+		var generator documentGenerator
+		generator, err = newDocumentGenerator(config, output, prefixSource)
+		if err != nil {
+			log.Err(err).Msgf("failed to initialize %s generator", output.Format)
+			os.Exit(8)
+		}
+		content, err = spliceOutput(generator, output, entries, renderConfig)
+	case splitMode != engine.SplitModeNone:
+		generator, err := newMDGenerator(config, output, prefixSource)
+		if err != nil {
+			log.Err(err).Msgf("failed to parse prefix markdown document: %s", output.Prefix)
+			os.Exit(8)
+		}
+		pages, err := generator.GenerateSplit(entries, splitMode)
+		if err != nil {
+			log.Err(err).Msgf("[generator error] failed to generate %s document", output.Format)
+			os.Exit(9)
+		}
+
+		log.Info().Msgf("writing %d pages to: %s", len(pages), output.Path)
+		if err = os.MkdirAll(output.Path, os.ModePerm); err != nil {
+			log.Err(err).Msgf("failed to initialize output directory: %s", output.Path)
+			os.Exit(10)
+		}
+		for _, page := range pages {
+			if err = writeDocument(page.Document, filepath.Join(output.Path, page.Name), renderConfig); err != nil {
+				log.Err(err).Msgf("cannot save page '%s' to output directory: %s", page.Name, output.Path)
+				os.Exit(10)
+			}
+		}
+		return
+	default:
+		generator, err := newDocumentGenerator(config, output, prefixSource)
+		if err != nil {
+			log.Err(err).Msgf("failed to initialize %s generator", output.Format)
+			os.Exit(8)
+		}
+		// markdown document is streamed into the output file instead of being held in memory
+		document, err := generator.Generate(entries)
+		if err != nil {
+			log.Err(err).Msgf("[generator error] failed to generate %s document", output.Format)
+			os.Exit(9)
+		}
+
+		log.Info().Msgf("writing content to: %s", output.Path)
+		if err = writeDocument(document, output.Path, renderConfig); err != nil {
+			log.Err(err).Msgf("cannot save results to output directory: %s", output.Path)
+			os.Exit(10)
+		}
+		return
 	}
-	content := string(contentBytes) + "\n\n"
-	generated, err := generate(request)
 	if err != nil {
-		log.Err(err).Msg("failed to generate markdown document")
+		log.Err(err).Msgf("failed to generate %s document", output.Format)
 		os.Exit(9)
 	}
-	content += generated
 
-	log.Info().Msgf("writing content to: %s", *output)
-	err = os.WriteFile(*output, []byte(content), 0644)
+	log.Info().Msgf("writing content to: %s", output.Path)
+	err = os.WriteFile(output.Path, []byte(content), 0644)
 	if err != nil {
-		log.Err(err).Msgf("cannot save results to output directory: %s", *output)
+		log.Err(err).Msgf("cannot save results to output directory: %s", output.Path)
 		os.Exit(10)
 	}
 }
@@ -154,19 +387,31 @@ func requestFromFiles(files []string) (*plugingo.CodeGeneratorRequest, error) {
 	}
 
 	return &plugingo.CodeGeneratorRequest{
-		FileToGenerate:  arrayutils.Map(files, func(v *string) string { return path.Base(*v) }),
+		FileToGenerate:  arrayutils.Map(files, func(v *string) string { return protoFileName(*v) }),
 		Parameter:       proto.String(strings.Join(parameters, ";")),
 		ProtoFile:       protos,
 		CompilerVersion: nil,
 	}, nil
 }
 
+// protoFileName returns the path of the file relative to the .proto files directory, file filters match this path
+func protoFileName(file string) string {
+	if rel, err := filepath.Rel(*dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+
+	return path.Base(file)
+}
+
 func protoc(files []string) ([]*descriptorpb.FileDescriptorProto, []string, error) {
 	parameters := make([]string, 0)
 	command := "protoc --proto_path=" + *dir + " "
+	for _, importPath := range splitPatterns(*importPaths) {
+		command += "--proto_path=" + importPath + " "
+	}
 	var fileNames []string
 	for _, file := range files {
-		fileName := path.Base(file)
+		fileName := protoFileName(file)
 		fileNames = append(fileNames, fileName)
 		mParam := fmt.Sprintf("M%s=%s", fileName, *dir)
 		command += fmt.Sprintf("--go_opt=M%s=%s ", fileName, *dir)
@@ -211,15 +456,112 @@ func protoc(files []string) ([]*descriptorpb.FileDescriptorProto, []string, erro
 	return result, parameters, nil
 }
 
-func generate(request *plugingo.CodeGeneratorRequest) (string, error) {
-	var content string
+func writeDocument(document *md.Document, output string, config *engine.Config) error {
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	renderer := engine.NewMarkdownRenderer(config)
+	if err = renderer.Render(file, document); err != nil {
+		return fmt.Errorf("[renderer error] %s", err.Error())
+	}
+
+	return file.Close()
+}
+
+// outputEntries returns the files documented for the audience and as of the version of the output or the filters
+func outputEntries(config *engine.PipelineConfig, output engine.OutputConfig, entries []engine.ParsedFile) []engine.ParsedFile {
+	audience, asOfVersion := config.Filters.Audience, config.Filters.AsOfVersion
+	if output.Audience != "" {
+		audience = output.Audience
+	}
+	if output.AsOfVersion != "" {
+		asOfVersion = output.AsOfVersion
+	}
+	if audience != "" {
+		entries = engine.FilterAudience(entries, audience)
+	}
+	if asOfVersion != "" {
+		entries = engine.FilterAsOf(entries, asOfVersion)
+	}
 
-	parser := engine.NewDescriptorParser(request)
-	generator := engine.NewMDGenerator(engine.NewCodegenerator())
-	renderer := engine.NewMarkdownRenderer(engine.DefaultRenderConfig())
-	entries, err := parser.Parse()
+	return entries
+}
+
+// splitPatterns splits semicolon separated values of the flags
+func splitPatterns(patterns string) []string {
+	if patterns == "" {
+		return nil
+	}
+
+	return strings.Split(patterns, ";")
+}
+
+func newCodegenerator(config *engine.PipelineConfig) *engine.Codegenerator {
+	codegen := engine.NewCodegenerator()
+	if config.Autocode.Seed != 0 {
+		codegen = engine.NewSeededCodegenerator(config.Autocode.Seed)
+	}
+	codegen.SetIncludeDeprecated(config.Autocode.IncludeDeprecated)
+
+	return codegen
+}
+
+func newMDGenerator(config *engine.PipelineConfig, output engine.OutputConfig, prefix string) (*engine.MDGenerator, error) {
+	// configuration is validated already
+	diagrams, _ := engine.ParseDiagramMode(output.Diagrams)
+	generator := engine.NewMDGenerator(newCodegenerator(config))
+	if prefix != "" {
+		if err := generator.SetPrefix(prefix); err != nil {
+			return nil, fmt.Errorf("invalid prefix document %s: %s", output.Prefix, err.Error())
+		}
+	}
+	generator.SetDiagrams(diagrams)
+	generator.SetHideDeprecated(output.TOC.HideDeprecated)
+	generator.SetSinceBadges(output.TOC.SinceBadges)
+	generator.SetHeadings(config.Headings)
+
+	return generator, nil
+}
+
+type documentGenerator interface {
+	Generate(parsedFiles []engine.ParsedFile) (*md.Document, error)
+}
+
+// newDocumentGenerator returns the template generator if the output has a template and the markdown generator otherwise
+func newDocumentGenerator(config *engine.PipelineConfig, output engine.OutputConfig, prefix string) (documentGenerator, error) {
+	if output.Template == "" {
+		return newMDGenerator(config, output, prefix)
+	}
+
+	generator := engine.NewTemplateGenerator(newCodegenerator(config))
+	if prefix != "" {
+		if err := generator.SetPrefix(prefix); err != nil {
+			return nil, fmt.Errorf("invalid prefix document %s: %s", output.Prefix, err.Error())
+		}
+	}
+	generator.SetOptions(engine.TemplateOptions{HideDeprecated: output.TOC.HideDeprecated, SinceBadges: output.TOC.SinceBadges})
+	generator.SetHeadings(config.Headings)
+	if output.Template != engine.DefaultTemplateName {
+		content, err := os.ReadFile(output.Template)
+		if err != nil {
+			return nil, err
+		}
+		if err = generator.Parse(output.Template, string(content)); err != nil {
+			return nil, fmt.Errorf("%s: %s", output.Template, err.Error())
+		}
+	}
+
+	return generator, nil
+}
+
+// spliceOutput generates the document and splices its regions into the existing output file
+func spliceOutput(generator documentGenerator, output engine.OutputConfig, entries []engine.ParsedFile, renderConfig *engine.Config) (string, error) {
+	existing, err := os.ReadFile(output.Path)
 	if err != nil {
-		return "", fmt.Errorf("[parser error] %s", err.Error())
+		return "", fmt.Errorf("failed to read splice target: %s", err.Error())
 	}
 
 	document, err := generator.Generate(entries)
@@ -227,14 +569,228 @@ func generate(request *plugingo.CodeGeneratorRequest) (string, error) {
 		return "", fmt.Errorf("[generator error] %s", err.Error())
 	}
 
-	content, err = renderer.Render(document)
+	regions, err := engine.RenderRegions(renderConfig, document)
 	if err != nil {
 		return "", fmt.Errorf("[renderer error] %s", err.Error())
 	}
 
+	content, err := engine.Splice(string(existing), regions)
+	if err != nil {
+		return "", fmt.Errorf("[splice error] %s: %s", output.Path, err.Error())
+	}
+
 	return content, nil
 }
 
+type apiDocument interface {
+	JSON() ([]byte, error)
+	YAML() ([]byte, error)
+}
+
+func generateAPI(config *engine.PipelineConfig, output engine.OutputConfig, entries []engine.ParsedFile) (string, error) {
+	var document apiDocument
+	switch output.Format {
+	case engine.OutputFormatAsyncAPI:
+		generator := engine.NewAsyncAPIGenerator(newCodegenerator(config), engine.AsyncAPIInfo{Version: output.API.Version}, output.API.Channel, output.API.ServerUrl)
+		asyncapi, err := generator.Generate(entries)
+		if err != nil {
+			return "", fmt.Errorf("[generator error] %s", err.Error())
+		}
+		document = asyncapi
+	default:
+		generator := engine.NewOpenAPIGenerator(newCodegenerator(config), engine.OpenAPIInfo{Version: output.API.Version})
+		openapi, err := generator.Generate(entries)
+		if err != nil {
+			return "", fmt.Errorf("[generator error] %s", err.Error())
+		}
+		document = openapi
+	}
+
+	var content []byte
+	var err error
+	if path.Ext(output.Path) == ".json" {
+		content, err = document.JSON()
+	} else {
+		content, err = document.YAML()
+	}
+	if err != nil {
+		return "", fmt.Errorf("[marshal error] %s", err.Error())
+	}
+
+	return string(content), nil
+}
+
+func generateDot(output engine.OutputConfig, entries []engine.ParsedFile) (string, error) {
+	clustering, err := engine.ParseDotClustering(output.Dot.Cluster)
+	if err != nil {
+		return "", err
+	}
+
+	generator := engine.NewDotGenerator(engine.DotOptions{
+		Clustering:    clustering,
+		Root:          output.Dot.Root,
+		Depth:         output.Dot.Depth,
+		HideWellKnown: output.Dot.HideWellKnown,
+	})
+	graph, err := generator.Generate(entries)
+	if err != nil {
+		return "", fmt.Errorf("[generator error] %s", err.Error())
+	}
+
+	return graph.String(), nil
+}
+
+// runDiff compares two API versions and writes the markdown changelog and optionally the JSON report
+func runDiff(args []string) {
+	flags := flag.NewFlagSet(diffCommand, flag.ExitOnError)
+	mdOutput := flags.String("o", "./changelog.md", "markdown changelog output file")
+	jsonOutput := flags.String("json", "", "JSON report output file, the report is not written if empty")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(flags.Output(), "Usage: %s diff [flags] <old> <new>\n"+
+			"<old> and <new> are .proto files directories or descriptor sets built with --include_source_info\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	oldFiles, err := parseInput(flags.Arg(0))
+	if err != nil {
+		log.Err(err).Msgf("failed to parse old version: %s", flags.Arg(0))
+		os.Exit(7)
+	}
+	newFiles, err := parseInput(flags.Arg(1))
+	if err != nil {
+		log.Err(err).Msgf("failed to parse new version: %s", flags.Arg(1))
+		os.Exit(7)
+	}
+
+	report := engine.Diff(oldFiles, newFiles)
+	log.Info().Msgf("%d changes found, writing changelog to: %s", len(report.Changes), *mdOutput)
+	if err = writeDocument(report.Markdown(), *mdOutput, engine.DefaultRenderConfig()); err != nil {
+		log.Err(err).Msgf("cannot save changelog: %s", *mdOutput)
+		os.Exit(10)
+	}
+	if *jsonOutput != "" {
+		content, err := report.JSON()
+		if err != nil {
+			log.Err(err).Msg("failed to marshal diff report")
+			os.Exit(9)
+		}
+		if err = os.WriteFile(*jsonOutput, content, 0644); err != nil {
+			log.Err(err).Msgf("cannot save diff report: %s", *jsonOutput)
+			os.Exit(10)
+		}
+	}
+}
+
+// runBreaking exits with 10 + severity code (11 info, 12 warning, 13 error) if the changes reach the -fail-on severity
+func runBreaking(args []string) {
+	flags := flag.NewFlagSet(breakingCommand, flag.ExitOnError)
+	failOn := flags.String("fail-on", "warning", "lowest severity of the changes, which fails the check: info, warning, error, none")
+	mdOutput := flags.String("o", "", "markdown report output file, the report is not written if empty")
+	jsonOutput := flags.String("json", "", "JSON report output file, the report is not written if empty")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(flags.Output(), "Usage: %s breaking [flags] <old> <new>\n"+
+			"<old> and <new> are .proto files directories or descriptor sets built with --include_source_info\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	threshold, err := engine.ParseSeverity(*failOn)
+	if err != nil {
+		log.Err(err).Msg("invalid -fail-on value")
+		os.Exit(1)
+	}
+
+	oldFiles, err := parseInput(flags.Arg(0))
+	if err != nil {
+		log.Err(err).Msgf("failed to parse old version: %s", flags.Arg(0))
+		os.Exit(7)
+	}
+	newFiles, err := parseInput(flags.Arg(1))
+	if err != nil {
+		log.Err(err).Msgf("failed to parse new version: %s", flags.Arg(1))
+		os.Exit(7)
+	}
+
+	report := engine.DetectBreaking(oldFiles, newFiles)
+	for _, change := range report.Changes {
+		log.Warn().Msgf("[%s] %s %s: %s", change.Severity, change.Rule, change.Name, change.Message)
+	}
+	if *mdOutput != "" {
+		if err = writeDocument(report.Markdown(), *mdOutput, engine.DefaultRenderConfig()); err != nil {
+			log.Err(err).Msgf("cannot save breaking changes report: %s", *mdOutput)
+			os.Exit(10)
+		}
+	}
+	if *jsonOutput != "" {
+		content, err := report.JSON()
+		if err != nil {
+			log.Err(err).Msg("failed to marshal breaking changes report")
+			os.Exit(9)
+		}
+		if err = os.WriteFile(*jsonOutput, content, 0644); err != nil {
+			log.Err(err).Msgf("cannot save breaking changes report: %s", *jsonOutput)
+			os.Exit(10)
+		}
+	}
+
+	severity := report.Severity()
+	if severity == engine.SeverityNone {
+		log.Info().Msg("no breaking changes found")
+		return
+	}
+	log.Info().Msgf("%d breaking changes found, highest severity: %s", len(report.Changes), severity)
+	if threshold != engine.SeverityNone && severity >= threshold {
+		os.Exit(10 + int(severity))
+	}
+}
+
+// parseInput parses .proto files of the directory with protoc or reads the descriptor set file
+func parseInput(input string) ([]engine.ParsedFile, error) {
+	stat, err := os.Stat(input)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read input %s: %s", input, err.Error())
+	}
+	if !stat.IsDir() {
+		content, err := os.ReadFile(input)
+		if err != nil {
+			return nil, err
+		}
+		set := &descriptorpb.FileDescriptorSet{}
+		if err = proto.Unmarshal(content, set); err != nil {
+			return nil, fmt.Errorf("failed to read descriptor set %s: %s", input, err.Error())
+		}
+		return engine.NewDescriptorSetParser(set).Parse()
+	}
+
+	checkDependencies()
+	*dir = path.Clean(input)
+	files, err := getProtoFilesRecursively(*dir)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "pb-md5-generator-diff")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	*pbOutput = tmp
+
+	request, err := requestFromFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.NewDescriptorParser(request).Parse()
+}
+
 func bash(cmd string) *exec.Cmd {
 	log.Trace().Msgf("executing cmd: %s", cmd)
 	return exec.Command("/usr/bin/bash", "-c", cmd)
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/kordax/pb-md5-generator/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtoFileName(t *testing.T) {
+	defer func(previous string) { *dir = previous }(*dir)
+	*dir = "protobufs"
+
+	assert.Equal(t, "api/v1/orders.proto", protoFileName("protobufs/api/v1/orders.proto"))
+	assert.Equal(t, "orders.proto", protoFileName("protobufs/orders.proto"))
+	assert.Equal(t, "orders.proto", protoFileName("other/orders.proto"))
+}
+
+func TestRequestFromFiles_filter(t *testing.T) {
+	for _, binary := range []string{"protoc", "protoc-gen-go"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s is not installed", binary)
+		}
+	}
+	defer func(previousDir, previousOutput string) { *dir, *pbOutput = previousDir, previousOutput }(*dir, *pbOutput)
+	*dir, *pbOutput = t.TempDir(), t.TempDir()
+
+	protos := map[string]string{
+		"api/v1/orders.proto":       "syntax = \"proto3\";\npackage shop.v1;\noption go_package = \"shop/v1\";\n\nmessage Order {\n  string id = 1;\n}\n",
+		"api/internal/secret.proto": "syntax = \"proto3\";\npackage shop.internal;\noption go_package = \"shop/internal\";\n\nmessage Secret {\n  string key = 1;\n}\n",
+	}
+	for name, content := range protos {
+		require.NoError(t, os.MkdirAll(filepath.Join(*dir, filepath.Dir(name)), os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(*dir, name), []byte(content), 0o644))
+	}
+
+	files, err := getProtoFilesRecursively(*dir)
+	require.NoError(t, err)
+	request, err := requestFromFiles(files)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"api/v1/orders.proto", "api/internal/secret.proto"}, request.FileToGenerate)
+
+	parsed, err := engine.NewDescriptorParser(request).Parse()
+	require.NoError(t, err)
+	filtered, err := engine.Filter(parsed, engine.FilterOptions{ExcludeFiles: []string{"**/internal/**"}})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+
+	document, err := engine.NewMDGenerator(engine.NewSeededCodegenerator(1)).Generate(filtered)
+	require.NoError(t, err)
+	content, err := engine.RenderString(engine.NewMarkdownRenderer(engine.DefaultRenderConfig()), document)
+	require.NoError(t, err)
+	assert.Contains(t, content, "shop.v1.Order")
+	assert.NotContains(t, content, "Secret")
+}